@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//maxRequestSize is the maximum size of the request body in bytes
+const maxRequestSize int64 = 1 << 16
+
+//apiCard is a single card in the request
+type apiCard struct {
+	Value  int  `json:"value"`
+	Damage int  `json:"damage"`
+	Played bool `json:"played"`
+}
+
+//apiHand is a hand in the request, selection is allowed only for the opponent who moved first
+type apiHand struct {
+	Health        int       `json:"health"`
+	Power         int       `json:"power"`
+	Cards         []apiCard `json:"cards"`
+	SelectedCard  *int      `json:"selected_card,omitempty"`
+	SelectedPower *int      `json:"selected_power,omitempty"` //omitted power of the selected card is hidden
+}
+
+//apiRequest is the game state for the bot move
+type apiRequest struct {
+	Seat      int     `json:"seat"` //index of the bot in the game, comp wins the tie
+	Hand      apiHand `json:"hand"`
+	Opponent  apiHand `json:"opponent"`
+	Seed      *int64  `json:"seed,omitempty"`      //seed for the repeatable bot decisions
+	Objective string  `json:"objective,omitempty"` //objective of the bot plans, default objective is used if empty
+}
+
+//apiResponse is the bot move and the fitness of the bot plan for the objective
+type apiResponse struct {
+	Card    int     `json:"card"`
+	Power   int     `json:"power"`
+	Fitness float32 `json:"fitness"`
+}
+
+//apiError is the response for the incorrect request
+type apiError struct {
+	Error string `json:"error"`
+}
+
+//moveServer calculates bot moves for the API requests
+type moveServer struct {
+	timeout time.Duration
+	workers chan struct{} //limits number of moves calculated at the same time
+}
+
+//hand will validate the hand and convert it to the game hand
+func (hand apiHand) hand(name string, selectionAllowed bool) (game.Hand, error) {
+	result := game.Hand{Health: hand.Health, Power: hand.Power, SelectedCard: -1}
+	if len(hand.Cards) < 1 || len(hand.Cards) > game.MaxHandSize {
+		return result, fmt.Errorf("%v: number of cards %v is out of range 1 .. %v", name, len(hand.Cards), game.MaxHandSize)
+	}
+	if hand.Health < 1 || hand.Health > game.MaxRulesValue {
+		return result, fmt.Errorf("%v: health %v is out of range 1 .. %v", name, hand.Health, game.MaxRulesValue)
+	}
+	if hand.Power < 0 || hand.Power > game.MaxRulesValue {
+		return result, fmt.Errorf("%v: power %v is out of range 0 .. %v", name, hand.Power, game.MaxRulesValue)
+	}
+	for i, v := range hand.Cards {
+		if v.Value < 1 || v.Value > game.MaxRulesValue {
+			return result, fmt.Errorf("%v: card %v value %v is out of range 1 .. %v", name, i, v.Value, game.MaxRulesValue)
+		}
+		if v.Damage < 0 || v.Damage > game.MaxRulesValue {
+			return result, fmt.Errorf("%v: card %v damage %v is out of range 0 .. %v", name, i, v.Damage, game.MaxRulesValue)
+		}
+		result.Cards = append(result.Cards, game.Card{Name: fmt.Sprint("Card ", i), Value: v.Value, Damage: v.Damage, Playable: !v.Played})
+	}
+	if result.PlayableCards() == 0 {
+		return result, fmt.Errorf("%v: all cards are played", name)
+	}
+
+	if hand.SelectedCard == nil {
+		if hand.SelectedPower != nil {
+			return result, fmt.Errorf("%v: selected_power is set without selected_card", name)
+		}
+		return result, nil
+	}
+	if !selectionAllowed {
+		return result, fmt.Errorf("%v: selected_card is allowed only for the opponent", name)
+	}
+	result.SelectedCard = *hand.SelectedCard
+	if result.SelectedCard < 0 || result.SelectedCard >= len(result.Cards) || !result.Cards[result.SelectedCard].Playable {
+		return result, fmt.Errorf("%v: selected_card %v is not a playable card", name, result.SelectedCard)
+	}
+	result.SelectedPower = game.HiddenPower
+	if hand.SelectedPower != nil {
+		result.SelectedPower = *hand.SelectedPower
+		if result.SelectedPower < 0 || result.SelectedPower > result.Power {
+			return result, fmt.Errorf("%v: selected_power %v is out of range 0 .. %v", name, result.SelectedPower, result.Power)
+		}
+	}
+	return result, nil
+}
+
+//state will validate the request and convert it to the player state
+func (request apiRequest) state() (State, error) {
+	var state State
+	var err error
+	if request.Objective != "" {
+		if err := validateObjective(request.Objective); err != nil {
+			return state, err
+		}
+	}
+	if request.Seat != game.Comp && request.Seat != game.User {
+		return state, fmt.Errorf("seat %v should be %v or %v", request.Seat, game.Comp, game.User)
+	}
+	state.Seat = request.Seat
+	if state.Hand, err = request.Hand.hand("hand", false); err != nil {
+		return state, err
+	}
+	if state.Opponent, err = request.Opponent.hand("opponent", true); err != nil {
+		return state, err
+	}
+	//Both players play one card every turn
+	if state.Hand.PlayableCards() != state.Opponent.PlayableCards() {
+		return state, fmt.Errorf("number of playable cards should be the same for both hands, got %v and %v", state.Hand.PlayableCards(), state.Opponent.PlayableCards())
+	}
+	return state, nil
+}
+
+//apiMove will calculate the bot move for the state, calculation stops early if the context is done
+func apiMove(ctx context.Context, state State, objective string, rnd *rand.Rand) apiResponse {
+	var response apiResponse
+	if cardNumber, cardPower, ok := lastCardMove(state.Hand); ok {
+		//Only the last card is left, so the fitness is calculated for the single plan
+		plan := chromosome{genes: []gene{{order: cardNumber, power: cardPower}}}
+		response.Card, response.Power = cardNumber, cardPower
+		response.Fitness = calcChromosomeFitness(plan, state.Hand, state.Opponent, opponentPlans(state.Opponent, rnd), state.Seat, objective, 0)
+		return response
+	}
+	plan := selectPlan(evolvePlans(ctx, state.Hand, state.Opponent, state.Seat, objective, nil, rnd), rnd)
+	response.Card, response.Power, response.Fitness = plan.genes[0].order, plan.genes[0].power, plan.fitness
+	return response
+}
+
+//writeJSON will write the value as the JSON response with the status code
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		Logger.Error(err)
+	}
+}
+
+//ServeHTTP will handle the move request
+func (s *moveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, apiError{Error: "only POST is allowed"})
+		return
+	}
+	var request apiRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: fmt.Sprint("incorrect JSON: ", err)})
+		return
+	}
+	state, err := request.state()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	seed := time.Now().UnixNano()
+	if request.Seed != nil {
+		seed = *request.Seed
+	}
+	objective := defaultObjective
+	if request.Objective != "" {
+		objective = request.Objective
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	//Wait for the free worker
+	select {
+	case s.workers <- struct{}{}:
+	case <-ctx.Done():
+		writeJSON(w, http.StatusServiceUnavailable, apiError{Error: "server is busy"})
+		return
+	}
+	responses := make(chan apiResponse, 1)
+	go func() {
+		//Calculation of the timed out request stops after the current generation, so the worker is released soon and the move is dropped
+		defer func() { <-s.workers }()
+		responses <- apiMove(ctx, state, objective, rand.New(rand.NewSource(seed)))
+	}()
+	select {
+	case response := <-responses:
+		writeJSON(w, http.StatusOK, response)
+	case <-ctx.Done():
+		writeJSON(w, http.StatusServiceUnavailable, apiError{Error: "move calculation timed out"})
+	}
+}
+
+//runAPI will serve the bot moves over HTTP
+func runAPI(args []string) {
+	flags := flag.NewFlagSet("api", flag.ExitOnError)
+	address := flags.String("addr", "localhost:8080", "HTTP address to listen on")
+	timeout := flags.Duration("timeout", 10*time.Second, "maximum time to calculate the move")
+	workers := flags.Int("workers", runtime.NumCPU(), "maximum number of moves calculated at the same time")
+	addBotFlags(flags)
+	gameRulesFlags := addRulesFlags(flags)
+	flags.Parse(args)
+	if err := gameRulesFlags.load(); err != nil {
+		Logger.Fatal(err)
+	}
+	if *workers < 1 {
+		Logger.Fatal("number of workers should be at least 1")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/move", &moveServer{timeout: *timeout, workers: make(chan struct{}, *workers)})
+	server := &http.Server{
+		Addr:              *address,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      *timeout + 10*time.Second,
+		IdleTimeout:       time.Minute,
+	}
+	Logger.Info("Listening on", *address)
+	Logger.Fatal(server.ListenAndServe())
+}
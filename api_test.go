@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+//testAPIRequest will create the request for the three cards hands with the seed
+func testAPIRequest() apiRequest {
+	seed := int64(1)
+	hand := apiHand{Health: 10, Power: 6, Cards: []apiCard{{Value: 3, Damage: 2}, {Value: 5, Damage: 3}, {Value: 7, Damage: 4}}}
+	opponent := apiHand{Health: 10, Power: 6, Cards: []apiCard{{Value: 4, Damage: 3}, {Value: 6, Damage: 2}, {Value: 2, Damage: 5}}}
+	return apiRequest{Seat: 0, Hand: hand, Opponent: opponent, Seed: &seed}
+}
+
+//postMove will send the request body to the move server and return the response
+func postMove(t *testing.T, s *moveServer, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/move", strings.NewReader(body)))
+	return recorder
+}
+
+//marshalRequest will convert the request to JSON
+func marshalRequest(t *testing.T, request apiRequest) string {
+	t.Helper()
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	return body.String()
+}
+
+func TestMoveServerMethod(t *testing.T) {
+	s := &moveServer{timeout: time.Minute, workers: make(chan struct{}, 1)}
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/move", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %v, want %v", recorder.Code, http.StatusMethodNotAllowed)
+	}
+	if allow := recorder.Header().Get("Allow"); allow != http.MethodPost {
+		t.Errorf("Allow = %q, want %q", allow, http.MethodPost)
+	}
+}
+
+func TestMoveServerBadRequest(t *testing.T) {
+	s := &moveServer{timeout: time.Minute, workers: make(chan struct{}, 1)}
+	selected := 5
+	tests := []struct {
+		name      string
+		body      string
+		change    func(request *apiRequest)
+		wantError string
+	}{
+		{"malformed JSON", `{"seat": 0, "hand": {`, nil, "incorrect JSON"},
+		{"unknown field", `{"seat": 0, "cards": []}`, nil, "incorrect JSON"},
+		{"card value above range", "", func(request *apiRequest) { request.Hand.Cards[0].Value = 101 }, "value 101 is out of range"},
+		{"zero card value", "", func(request *apiRequest) { request.Opponent.Cards[1].Value = 0 }, "value 0 is out of range"},
+		{"negative damage", "", func(request *apiRequest) { request.Hand.Cards[2].Damage = -1 }, "damage -1 is out of range"},
+		{"negative power", "", func(request *apiRequest) { request.Hand.Power = -1 }, "power -1 is out of range"},
+		{"power above range", "", func(request *apiRequest) { request.Opponent.Power = 101 }, "power 101 is out of range"},
+		{"zero health", "", func(request *apiRequest) { request.Hand.Health = 0 }, "health 0 is out of range"},
+		{"health above range", "", func(request *apiRequest) { request.Opponent.Health = 101 }, "health 101 is out of range"},
+		{"selected card out of range", "", func(request *apiRequest) { request.Opponent.SelectedCard = &selected }, "selected_card 5 is not a playable card"},
+		{"selected power above the hand power", "", func(request *apiRequest) {
+			card, power := 0, 7
+			request.Opponent.SelectedCard, request.Opponent.SelectedPower = &card, &power
+		}, "selected_power 7 is out of range"},
+		{"unknown objective", "", func(request *apiRequest) { request.Objective = "luck" }, "unknown objective"},
+		{"bad seat", "", func(request *apiRequest) { request.Seat = 2 }, "seat 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			if tt.change != nil {
+				request := testAPIRequest()
+				tt.change(&request)
+				body = marshalRequest(t, request)
+			}
+			recorder := postMove(t, s, body)
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %v, want %v, body %v", recorder.Code, http.StatusBadRequest, recorder.Body)
+			}
+			var response apiError
+			if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(response.Error, tt.wantError) {
+				t.Errorf("error %q doesn't contain %q", response.Error, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestMoveServerSeed(t *testing.T) {
+	s := &moveServer{timeout: time.Minute, workers: make(chan struct{}, 1)}
+	body := marshalRequest(t, testAPIRequest())
+	var responses [2]string
+	for i := range responses {
+		recorder := postMove(t, s, body)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %v, want %v, body %v", recorder.Code, http.StatusOK, recorder.Body)
+		}
+		responses[i] = recorder.Body.String()
+	}
+	if responses[0] != responses[1] {
+		t.Errorf("responses %v and %v are different for the same seed", responses[0], responses[1])
+	}
+	var response apiResponse
+	if err := json.Unmarshal([]byte(responses[0]), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Card < 0 || response.Card > 2 || response.Power < 0 || response.Power > 6 {
+		t.Errorf("move %+v is not legal", response)
+	}
+}
+
+func TestMoveServerTimeout(t *testing.T) {
+	savedGenerations, savedPlateau := generationsLimit, plateauLimit
+	defer func() { generationsLimit, plateauLimit = savedGenerations, savedPlateau }()
+	//Evolution doesn't finish without the timeout
+	generationsLimit, plateauLimit = 1<<30, 1<<30
+	s := &moveServer{timeout: 50 * time.Millisecond, workers: make(chan struct{}, 1)}
+	recorder := postMove(t, s, marshalRequest(t, testAPIRequest()))
+	if recorder.Code != http.StatusServiceUnavailable || !strings.Contains(recorder.Body.String(), "timed out") {
+		t.Fatalf("status = %v, body %v, want the timeout", recorder.Code, recorder.Body)
+	}
+	//Calculation is cancelled, so the worker is released for the next request
+	deadline := time.Now().Add(10 * time.Second)
+	for len(s.workers) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("worker is not released after the timeout")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMoveServerBusy(t *testing.T) {
+	s := &moveServer{timeout: 50 * time.Millisecond, workers: make(chan struct{}, 1)}
+	s.workers <- struct{}{}
+	recorder := postMove(t, s, marshalRequest(t, testAPIRequest()))
+	if recorder.Code != http.StatusServiceUnavailable || !strings.Contains(recorder.Body.String(), "busy") {
+		t.Errorf("status = %v, body %v, want the busy server", recorder.Code, recorder.Body)
+	}
+}
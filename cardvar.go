@@ -2,12 +2,12 @@ package main
 
 //CardData is a simple struct to store card params in DB
 type CardData struct {
-	power  int
-	damage int
+	Power  int `json:"value"` //card value
+	Damage int `json:"damage"`
 }
 
-//CardDB is card database
-var CardDB = [4][7]CardData{
+//CardDB is card database, every row is a tier of cards with similar power
+var CardDB = [][]CardData{
 	{{3, 2}, {3, 1}, {3, 3}, {2, 2}, {4, 2}, {2, 1}, {4, 3}},
 	{{4, 3}, {4, 2}, {4, 4}, {3, 3}, {5, 3}, {3, 2}, {5, 4}},
 	{{6, 5}, {6, 4}, {6, 6}, {5, 5}, {7, 5}, {5, 4}, {7, 6}},
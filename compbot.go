@@ -1,34 +1,39 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"hash/fnv"
-	"math"
 	"math/rand"
+	"runtime"
 	"sort"
-	"time"
+	"sync"
+
+	"github.com/zerobugdebug/kaart/game"
 )
 
 //Genetic algorithm parameters
 var (
 	maxPopulationSize      int     = 100   //size of the population
-	generationsLimit       int     = 2     //how many generations to generate
+	generationsLimit       int     = 20    //how many generations to generate
+	plateauLimit           int     = 5     //stop after this number of generations without improvement of the best fitness
 	crossoverRate          float32 = 0.9   //how often to do crossover 0%-100% in decimal
 	mutationRate           float32 = 0.9   //how often to do mutation 0%-100% in decimal
 	elitismRate            float32 = 0.2   //how many of the best indviduals to keep intact
-	deadend                float32 = 10000 //round number to split between unscheduled tasks and real hours to complete
 	tourneySampleSize      int     = 3     //sample size for the tournament selection, should be less than population size-number of elites
 	crossoverParentsNumber int     = 2     //number of parents for the crossover
 	maxCrossoverLength     int     = 3     //max number of sequential tasks to cross between Chromosomes
 	maxMutatedGenes        int     = 3     //maximum number of mutated genes, min=2
 	mutationTypePreference float32 = 0.5   //prefered mutation type rate. 0 = 100% swap mutation, 1 = 100% displacement mutation
+	powerMutationRate      float32 = 0.5   //how often to redistribute power between genes instead of changing the card order
+	maxStaleAttempts       int     = 10    //number of attempts to get new chromosome from crossover/mutation before adding a random one
+	maxOpponentPlans       int     = 12000 //maximum number of opponent plans to calculate the fitness, random sample is used for the larger hands
+	mixedPlanMargin        float32 = 0.02  //in the simultaneous mode the plan is selected randomly from plans with fitness within this margin from the best
 )
 
-const (
-	threadsNum int = 256 //number of go routines to run simultaneously
-	//handSize   int = 4
-	//maxPower   int = 12
-)
+//threadsNum is the number of go routines to calculate the fitness simultaneously, fitness is calculated serially if it is 1 or less
+var threadsNum int = runtime.NumCPU()
 
 //Gene represents one gene in the chromosome
 type gene struct {
@@ -42,33 +47,27 @@ type chromosome struct {
 	fitness float32
 }
 
+//opponentPlan is the order of the opponent cards and the power for every card
+type opponentPlan struct {
+	order  []int
+	power  []int
+	weight float32 //probability of the plan relative to other plans, all plans have the same weight without the opponent model
+}
+
 //Population is a struct for the chromosomes and their hashes
 type population struct {
 	hashes      map[uint64]int
 	chromosomes []chromosome
 }
 
-/*
-//Packing slice of bytes to uint64 using the minimum amount of bits
-func packBytes(unpacked []byte) uint64 {
-	var packed uint64
-	bitSize := bits.Len(uint(MaxPower))
-	for _, v := range unpacked {
-		packed = packed<<bitSize + uint64(v)
-	}
-	return packed
-}
-*/
-
+//Calculate hash for the card order and power of the genes, both fit in a byte, because the hand size and the power are limited by the rules
 func calcGenesHash(genes []gene) uint64 {
-
-	//Convert slice into string representation
-	allGenes := fmt.Sprint(genes)
-	Logger.Debug("allGenes=", allGenes)
-
-	//Calculate hash
+	packed := make([]byte, 0, 2*len(genes))
+	for _, v := range genes {
+		packed = append(packed, byte(v.order), byte(v.power))
+	}
 	hashAlg := fnv.New64a()
-	hashAlg.Write([]byte(allGenes))
+	hashAlg.Write(packed)
 	return hashAlg.Sum64()
 }
 
@@ -87,21 +86,21 @@ func calcChromosomesHash(chromosomes []chromosome) map[uint64]int {
 }
 
 //GenerateChromosome will generate a new chromosome for the GA
-func generateChromosome(hand Hand) chromosome {
+func generateChromosome(hand game.Hand, rnd *rand.Rand) chromosome {
 	var newChromosome chromosome
 	var lenHand int
-	for _, v := range hand.cards {
-		if v.playable {
+	for _, v := range hand.Cards {
+		if v.Playable {
 			lenHand++
 		}
 	}
 
-	cardsOrder := rand.Perm(lenHand)
+	cardsOrder := rnd.Perm(lenHand)
 	var cardsPower []int
 	cardsPower = make([]int, lenHand)
 	totalPower := 0
 	for i := range cardsPower {
-		cardsPower[i] = rand.Intn(hand.power - totalPower + 1)
+		cardsPower[i] = rnd.Intn(hand.Power - totalPower + 1)
 		totalPower += cardsPower[i]
 	}
 	Logger.Debug(cardsOrder)
@@ -121,30 +120,25 @@ func generateChromosome(hand Hand) chromosome {
 }
 
 //GeneratePopulation will generate full population
-func generatePopulation(hand Hand) population {
+func generatePopulation(hand game.Hand, rnd *rand.Rand) population {
 	var population population
 	var chromosome chromosome
 	var remainingChromosomesNumber, numPlayableCards, maxAvailableVariants int
 
 	remainingChromosomesNumber = maxPopulationSize
 	//Calculate maximum number of permutations
-	for _, v := range hand.cards {
-		if v.playable {
+	for _, v := range hand.Cards {
+		if v.Playable {
 			numPlayableCards++
 		}
 	}
-	//Generate temp slice to store card order numbers
-	tempSlice := make([]int, numPlayableCards)
-	for i := range tempSlice {
-		tempSlice[i] = i
-	}
 	//Get the number of all possible orders of the cards for the specific amount of cards
-	maxAvailableVariants = len(GetAllPermutations(tempSlice))
+	maxAvailableVariants = CountPermutations(numPlayableCards)
 	Logger.Debug("maxAvailableVariants=", maxAvailableVariants)
 	//Check to see if only card order variants emough to cover maxPopulationSize
 	if maxAvailableVariants < maxPopulationSize {
-		//Calculate number of possible combinations to get to the available hand.power and multiple it to the card order variants
-		maxAvailableVariants *= len(GetAllPermutationsForSum(numPlayableCards, hand.power))
+		//Calculate number of possible combinations to get to the available hand.Power and multiple it to the card order variants
+		maxAvailableVariants *= CountPermutationsForSum(numPlayableCards, hand.Power)
 	}
 	Logger.Debug("maxAvailableVariants=", maxAvailableVariants)
 	//Select the min(maxAvailableVariants, maxPopulationSize) as remainingChromosomesNumber
@@ -155,7 +149,7 @@ func generatePopulation(hand Hand) population {
 
 	population.hashes = make(map[uint64]int)
 	for condition := true; condition; condition = remainingChromosomesNumber > 0 {
-		chromosome = generateChromosome(hand)
+		chromosome = generateChromosome(hand, rnd)
 		Logger.Debug(chromosome)
 		hash := calcChromosomeHash(chromosome)
 		Logger.Debug(hash)
@@ -185,49 +179,48 @@ func copyChromosomes(oldChromosomes []chromosome) []chromosome {
 	return newChromosomes
 }
 
-func copyPopulation(oldPopulation population) population {
-	var newPopulation population
-	//Copy chromosomes
-	newPopulation.chromosomes = copyChromosomes(oldPopulation.chromosomes)
-
-	//Copy hashes
-	newPopulation.hashes = make(map[uint64]int)
-	for k, v := range oldPopulation.hashes {
-		newPopulation.hashes[k] = v
+//ElitesNumber will return number of the best chromosomes to keep intact in the next generation
+func elitesNumber(populationSize int) int {
+	elitesNum := int(elitismRate * float32(populationSize))
+	//Always keep the best chromosome, so the fitness never goes down between generations
+	if elitesNum < 1 {
+		elitesNum = 1
 	}
-	return newPopulation
+	return elitesNum
 }
 
 //TransmogrifyPopulation will apply crossovers and mutations on non-elite Chromosomes
-func transmogrifyPopulation(pop population) population {
-	elitesNum := int(elitismRate * float32(len(pop.chromosomes)))
+func transmogrifyPopulation(pop population, hand game.Hand, rnd *rand.Rand) population {
+	elitesNum := elitesNumber(len(pop.chromosomes))
 	//Logger.Info("elitesNum=", elitesNum)
 	var newPopulation population
 	var tempChromosomes []chromosome
 	//Keep elites in the new population
-	//	newPopulation = population[:elitesNum]
-	//Logger.Info("OldElite=", population[0])
 	newPopulation.chromosomes = copyChromosomes(pop.chromosomes[:elitesNum])
 	//Recalculate hash for the elites
-	//newPopulation.hashes = calcChromosomesHash(newPopulation.Chromosomes)
-	//Logger.Info("NewElite=", newPopulation[0])
+	newPopulation.hashes = calcChromosomesHash(newPopulation.chromosomes)
 	Logger.Debug("newPopulation size with elites =", len(newPopulation.chromosomes))
 	Logger.Debug("Best elite fitness =", newPopulation.chromosomes[0].fitness)
-	//LoggerFile.Info("ELITES:", newPopulation[0].tasks)
 	remainingChromosomesNumber := len(pop.chromosomes) - elitesNum
 	Logger.Debug("remainingChromosomesNumber =", remainingChromosomesNumber)
+	staleAttempts := 0
 	//Generate len(population)-elitesNum additonal Chromosomes
-	for condition := true; condition; condition = remainingChromosomesNumber > 0 {
-		tempChromosomes = make([]chromosome, crossoverParentsNumber)
-		//Select crossoverParentsNumber from the population with Torunament Selection
-		tempChromosomes = tourneySelect(pop.chromosomes, crossoverParentsNumber)
-		Logger.Debug("tempPopulation size after tourney =", len(tempChromosomes))
-		//Apply crossover to the tempPopulation
-		//             tempChromosomes = crossoverChromosomesOX1(tempChromosomes)
-		Logger.Debug("tempPopulation size after crossover =", len(tempChromosomes))
-		//Apply mutation to the tempPopulation
-		tempChromosomes = mutateChromosomes(tempChromosomes)
-		Logger.Debug("tempPopulation size after mutation =", len(tempChromosomes))
+	for remainingChromosomesNumber > 0 {
+		if staleAttempts < maxStaleAttempts {
+			//Select crossoverParentsNumber from the population with Torunament Selection
+			tempChromosomes = tourneySelect(pop.chromosomes, crossoverParentsNumber, rnd)
+			Logger.Debug("tempPopulation size after tourney =", len(tempChromosomes))
+			//Apply crossover to the tempPopulation
+			tempChromosomes = crossoverChromosomesOX1(tempChromosomes, hand, rnd)
+			Logger.Debug("tempPopulation size after crossover =", len(tempChromosomes))
+			//Apply mutation to the tempPopulation
+			tempChromosomes = mutateChromosomes(tempChromosomes, hand, rnd)
+			Logger.Debug("tempPopulation size after mutation =", len(tempChromosomes))
+		} else {
+			//Offspring are duplicates of the existing chromosomes, add random one to keep the diversity
+			tempChromosomes = []chromosome{generateChromosome(hand, rnd)}
+		}
+		staleAttempts++
 		//Append tempPopulation to the new population, if indviduals are new
 		for _, v := range tempChromosomes {
 			tempHash := calcChromosomeHash(v)
@@ -238,13 +231,13 @@ func transmogrifyPopulation(pop population) population {
 				//Add Chromosome to the Chromosomes slice
 				newPopulation.chromosomes = append(newPopulation.chromosomes, copyChromosome(v))
 				remainingChromosomesNumber--
+				staleAttempts = 0
 			}
 		}
 
 		Logger.Debug("newPopulation size =", len(newPopulation.chromosomes))
 		//Update remaining number of Chromosomes to generate
 		Logger.Debug("remainingChromosomesNumber =", remainingChromosomesNumber)
-		Logger.Debug("condition =", condition)
 	}
 
 	Logger.Debug("newPopulation.hashes=", newPopulation.hashes)
@@ -255,25 +248,31 @@ func transmogrifyPopulation(pop population) population {
 }
 
 //Tournament selection for the crossover
-func tourneySelect(chromosomes []chromosome, number int) []chromosome {
+func tourneySelect(chromosomes []chromosome, number int, rnd *rand.Rand) []chromosome {
 	//Create slice of randmoly permutated Chromosomes numbers
-	sampleOrder := rand.Perm(len(chromosomes))
+	sampleOrder := rnd.Perm(len(chromosomes))
 	Logger.Debug("sampleOrder =", sampleOrder)
 
 	var bestChromosomes []chromosome
 	var bestChromosomeNumber int
 	var sampleOrderNumber int
 	var bestChromosomeFitness float32
-	for i := 0; i < number; i++ {
+	var sampleSize int
+	for i := 0; i < number && len(sampleOrder) > 0; i++ {
 		Logger.Debug("Processing Chromosome =", i)
 
 		bestChromosomeNumber = 0
 		sampleOrderNumber = 0
-		bestChromosomeFitness = float32(math.MaxFloat32)
-		//Select best Chromosome number from first tourneySampleSize elements in sampleOrder
-		for j, v := range sampleOrder[:tourneySampleSize] {
+		bestChromosomeFitness = -1
+		//Small populations can have less chromosomes than tourneySampleSize
+		sampleSize = tourneySampleSize
+		if sampleSize > len(sampleOrder) {
+			sampleSize = len(sampleOrder)
+		}
+		//Select best Chromosome number from first sampleSize elements in sampleOrder
+		for j, v := range sampleOrder[:sampleSize] {
 			Logger.Debugf("Processing sample %v, sample value %v", j, v)
-			if chromosomes[v].fitness < bestChromosomeFitness {
+			if chromosomes[v].fitness > bestChromosomeFitness {
 				bestChromosomeNumber = v
 				bestChromosomeFitness = chromosomes[v].fitness
 				sampleOrderNumber = j
@@ -292,135 +291,388 @@ func tourneySelect(chromosomes []chromosome, number int) []chromosome {
 		sampleOrder[sampleOrderNumber] = sampleOrder[len(sampleOrder)-1]
 		sampleOrder = sampleOrder[:len(sampleOrder)-1]
 		//Shuffle remaining Chromosome numbers
-		rand.Shuffle(len(sampleOrder), func(i, j int) { sampleOrder[i], sampleOrder[j] = sampleOrder[j], sampleOrder[i] })
+		rnd.Shuffle(len(sampleOrder), func(i, j int) { sampleOrder[i], sampleOrder[j] = sampleOrder[j], sampleOrder[i] })
 		Logger.Debug("new sampleOrder =", sampleOrder)
 
 	}
 	return bestChromosomes
 }
 
-func displacementMutation(chromosome chromosome) chromosome {
-	//Randomly select number of genes to mutate, but at least 1
-	//numOfGenesToMutate := rand.Intn(maxMutatedGenes) + 1
-	/* 	for i := 0; i < numOfGenesToMutate; i++ {
-	   		//Generate random old position for the gene between 0 and one element before last
-	   		oldPosition := rand.Intn(len(Chromosome.genes)*8 - 1)
-	   		//Generate random new position for the gene between oldPosition+1 and last element
-	   		newPosition := rand.Intn(len(Chromosome.genes)*8-oldPosition-1) + oldPosition + 1
-	   		//Store the original taskID at the oldPosition
-	   		oldBit := Chromosome.genes[oldPosition/8] &
-	   		//Shift all taskIDs one task back
-	   		for j := range Chromosome.tasks[oldPosition:newPosition] {
-	   			Chromosome.tasks[oldPosition+j].taskID = Chromosome.tasks[oldPosition+j+1].taskID
-	   		}
-	   		//Restore the original taskID to the newPosition
-	   		Chromosome.tasks[newPosition].taskID = oldTaskID
-	   	}
-	*/
+//CrossoverChromosomesOX1 will apply order crossover (OX1) to the card order and repair power budget of the offspring
+func crossoverChromosomesOX1(chromosomes []chromosome, hand game.Hand, rnd *rand.Rand) []chromosome {
+	var childChromosomes []chromosome
+	//Copy parents to child Chromosomes slice, if there is no crossover
+	if len(chromosomes) < 2 || rnd.Float32() >= crossoverRate {
+		return copyChromosomes(chromosomes)
+	}
+	for i := range chromosomes {
+		//Every parent crosses with the next one, last one crosses with the first
+		childChromosomes = append(childChromosomes, crossoverGenesOX1(chromosomes[i], chromosomes[(i+1)%len(chromosomes)], hand.Power, rnd))
+	}
+	return childChromosomes
+}
+
+//CrossoverGenesOX1 will create one child from the sequence of genes of the first parent and remaining cards in order of the second parent
+func crossoverGenesOX1(firstParent chromosome, secondParent chromosome, maxPower int, rnd *rand.Rand) chromosome {
+	var child chromosome
+	genesNum := len(firstParent.genes)
+	child.genes = make([]gene, genesNum)
+	copy(child.genes, firstParent.genes)
+	if genesNum < 2 {
+		return child
+	}
+	//Randomly select length of the crossover sequence, but not the full chromosome
+	crossoverLen := maxCrossoverLength
+	if crossoverLen > genesNum-1 {
+		crossoverLen = genesNum - 1
+	}
+	crossoverLen = rnd.Intn(crossoverLen) + 1
+	crossoverStart := rnd.Intn(genesNum - crossoverLen + 1)
+	crossoverEnd := crossoverStart + crossoverLen
+
+	//Mark cards already taken from the first parent
+	usedCards := make(map[int]bool)
+	for _, v := range child.genes[crossoverStart:crossoverEnd] {
+		usedCards[v.order] = true
+	}
+	//Fill remaining positions after the crossover sequence with the cards of the second parent, starting after the sequence
+	childPosition := crossoverEnd % genesNum
+	for i := range secondParent.genes {
+		parentGene := secondParent.genes[(crossoverEnd+i)%genesNum]
+		if usedCards[parentGene.order] {
+			continue
+		}
+		child.genes[childPosition].order = parentGene.order
+		childPosition = (childPosition + 1) % genesNum
+	}
+	//Power outside the crossover sequence is taken from the second parent on the same positions
+	for i := range child.genes {
+		if i < crossoverStart || i >= crossoverEnd {
+			child.genes[i].power = secondParent.genes[i].power
+		}
+	}
+	return repairGenesPower(child, maxPower, rnd)
+}
+
+//RepairGenesPower will randomly remove power from the genes until total power is within maxPower
+func repairGenesPower(chromosome chromosome, maxPower int, rnd *rand.Rand) chromosome {
+	var totalPower int
+	var poweredGenes []int
+	for i, v := range chromosome.genes {
+		totalPower += v.power
+		if v.power > 0 {
+			poweredGenes = append(poweredGenes, i)
+		}
+	}
+	for totalPower > maxPower {
+		i := rnd.Intn(len(poweredGenes))
+		chromosome.genes[poweredGenes[i]].power--
+		totalPower--
+		//Remove gene from the selection, if there is no power left
+		if chromosome.genes[poweredGenes[i]].power == 0 {
+			poweredGenes[i] = poweredGenes[len(poweredGenes)-1]
+			poweredGenes = poweredGenes[:len(poweredGenes)-1]
+		}
+	}
+	return chromosome
+}
+
+//DisplacementMutation will move one gene (card with its power) to another position and shift genes in between
+func displacementMutation(chromosome chromosome, rnd *rand.Rand) chromosome {
+	if len(chromosome.genes) < 2 {
+		return chromosome
+	}
+	//Randomly select number of genes to mutate, but at least 2
+	numOfGenesToMutate := rnd.Intn(maxMutatedGenes-1) + 2
+	if numOfGenesToMutate > len(chromosome.genes) {
+		numOfGenesToMutate = len(chromosome.genes)
+	}
+	//Generate random old position for the gene, new position is numOfGenesToMutate-1 genes away
+	oldPosition := rnd.Intn(len(chromosome.genes) - numOfGenesToMutate + 1)
+	newPosition := oldPosition + numOfGenesToMutate - 1
+	//Move gene forward or backward with the same probability
+	if rnd.Intn(2) == 0 {
+		oldPosition, newPosition = newPosition, oldPosition
+	}
+	//Store the original gene at the oldPosition
+	oldGene := chromosome.genes[oldPosition]
+	//Shift all genes between oldPosition and newPosition one position towards oldPosition
+	if oldPosition < newPosition {
+		copy(chromosome.genes[oldPosition:newPosition], chromosome.genes[oldPosition+1:newPosition+1])
+	} else {
+		copy(chromosome.genes[newPosition+1:oldPosition+1], chromosome.genes[newPosition:oldPosition])
+	}
+	//Restore the original gene to the newPosition
+	chromosome.genes[newPosition] = oldGene
 	return chromosome
 }
 
-func swapMutation(chromosome chromosome) chromosome {
-	//Randomly select number of genes to mutate, but at least 1
-	/* 	numOfGenesToMutate := rand.Intn(maxMutatedGenes-1) + 1
-	   	sampleOrder := rand.Perm(len(Chromosome.tasks))
-	   	for i := 0; i < numOfGenesToMutate; i++ {
-	   		//Swap taskIDs for the task with number sampleOrder[i] and sampleOrder[len(Chromosome.tasks)-1] to make it easier to account for the border values
-	   		Chromosome.tasks[sampleOrder[i]].taskID, Chromosome.tasks[sampleOrder[len(Chromosome.tasks)-i-1]].taskID = Chromosome.tasks[sampleOrder[len(Chromosome.tasks)-i-1]].taskID, Chromosome.tasks[sampleOrder[i]].taskID
-	   	}
-	*/return chromosome
+//SwapMutation will rotate card order between randomly selected genes, power of every gene stays intact
+func swapMutation(chromosome chromosome, rnd *rand.Rand) chromosome {
+	if len(chromosome.genes) < 2 {
+		return chromosome
+	}
+	//Randomly select number of genes to mutate, but at least 2
+	numOfGenesToMutate := rnd.Intn(maxMutatedGenes-1) + 2
+	if numOfGenesToMutate > len(chromosome.genes) {
+		numOfGenesToMutate = len(chromosome.genes)
+	}
+	sampleOrder := rnd.Perm(len(chromosome.genes))[:numOfGenesToMutate]
+	//Shift card order one gene forward in the sample, 2 genes mutation is a simple swap
+	firstOrder := chromosome.genes[sampleOrder[0]].order
+	for i := range sampleOrder[:numOfGenesToMutate-1] {
+		chromosome.genes[sampleOrder[i]].order = chromosome.genes[sampleOrder[i+1]].order
+	}
+	chromosome.genes[sampleOrder[numOfGenesToMutate-1]].order = firstOrder
+	return chromosome
+}
+
+//PowerMutation will move random amount of power from one gene to another, unused power can be used as donor or receiver
+func powerMutation(chromosome chromosome, maxPower int, rnd *rand.Rand) chromosome {
+	unusedPower := maxPower
+	for _, v := range chromosome.genes {
+		unusedPower -= v.power
+	}
+	//Last position is reserved for the unused power
+	powers := make([]int, len(chromosome.genes)+1)
+	for i, v := range chromosome.genes {
+		powers[i] = v.power
+	}
+	powers[len(chromosome.genes)] = unusedPower
+
+	//Select donor from genes with some power
+	var donors []int
+	for i, v := range powers {
+		if v > 0 {
+			donors = append(donors, i)
+		}
+	}
+	if len(donors) == 0 {
+		return chromosome
+	}
+	donor := donors[rnd.Intn(len(donors))]
+	//Select receiver from all other genes
+	receiver := rnd.Intn(len(powers) - 1)
+	if receiver >= donor {
+		receiver++
+	}
+	transferredPower := rnd.Intn(powers[donor]) + 1
+	powers[donor] -= transferredPower
+	powers[receiver] += transferredPower
 
+	for i := range chromosome.genes {
+		chromosome.genes[i].power = powers[i]
+	}
+	return chromosome
 }
 
-func mutateChromosomes(chromosomes []chromosome) []chromosome {
+func mutateChromosomes(chromosomes []chromosome, hand game.Hand, rnd *rand.Rand) []chromosome {
 	var mutatedChromosomes []chromosome
-	/* 	//var crossoverStart, crossoverEnd, crossoverLen int
-	   	//Copy parent to child Chromosomes slice
-	   	//mutatedChromosomes = make([]Chromosome, len(Chromosomes))
-	   	mutatedChromosomes = copyChromosomes(Chromosomes)
-	   	for i := range mutatedChromosomes {
-	   		//Check if we need to mutate
-	   		if rand.Float32() < mutationRate {
-	   			if rand.Float32() < mutationTypePreference {
-	   				//Do the displacement mutation
-	   				mutatedChromosomes[i] = displacementMutation(mutatedChromosomes[i])
-	   			} else {
-	   				//Do the swap mutation
-	   				mutatedChromosomes[i] = swapMutation(mutatedChromosomes[i])
-	   			}
-	   		}
-	   	} */
+	//Copy parent to child Chromosomes slice
+	mutatedChromosomes = copyChromosomes(chromosomes)
+	for i := range mutatedChromosomes {
+		//Check if we need to mutate
+		if rnd.Float32() < mutationRate {
+			if rnd.Float32() < powerMutationRate {
+				//Do the power mutation
+				mutatedChromosomes[i] = powerMutation(mutatedChromosomes[i], hand.Power, rnd)
+			} else if rnd.Float32() < mutationTypePreference {
+				//Do the displacement mutation
+				mutatedChromosomes[i] = displacementMutation(mutatedChromosomes[i], rnd)
+			} else {
+				//Do the swap mutation
+				mutatedChromosomes[i] = swapMutation(mutatedChromosomes[i], rnd)
+			}
+		}
+	}
 	return mutatedChromosomes
 }
 
-func calcChromosomesFitness(chromosomes []chromosome, compHand Hand, userHand Hand) {
-	for i, v := range chromosomes {
-		chromosomes[i].fitness = calcChromosomeFitness(v, compHand, userHand)
+//opponentPlans will return all orders and power distributions of the opponent cards, random sample of maxOpponentPlans is returned if there are more of them.
+//If the opponent moved first, every plan starts with the selected card and the selected power, if it is visible
+func opponentPlans(hand game.Hand, rnd *rand.Rand) []opponentPlan {
+	var firstOrder, firstPower []int
+	restHand := hand
+	totalPower := hand.Power
+	if hand.SelectedCard >= 0 {
+		restHand = hand.Copy()
+		restHand.Cards[hand.SelectedCard].Playable = false
+		firstOrder = []int{hand.SelectedCard}
+		if hand.SelectedPower != game.HiddenPower {
+			firstPower = []int{hand.SelectedPower}
+			totalPower -= hand.SelectedPower
+		}
 	}
+	numCards := restHand.PlayableCards()
+	//Hidden power of the selected card is distributed together with the power of the remaining cards
+	numPowers := numCards + len(firstOrder) - len(firstPower)
+	//Power left after the last card with the visible power is lost
+	if numPowers == 0 {
+		totalPower = 0
+	}
+	plansNum := CountPermutations(numCards) * CountPermutationsForSum(numPowers, totalPower)
+	if plansNum <= maxOpponentPlans {
+		plans := make([]opponentPlan, 0, plansNum)
+		//Only the selected card with the visible power is left, if there are no other cards, tables have the single empty permutation then
+		cardOrders := CachedPermutations(numCards)
+		//Get all possible power combinations, power tables are shared by all plans
+		cardPowers := CachedPermutationsForSum(numPowers, totalPower)
+		//Get all possible orders of the cards for the specific amount of cards
+		for _, relativeOrder := range cardOrders {
+			//Cached order is converted to the absolute order in the copy
+			cardOrder := append(append([]int(nil), firstOrder...), convertCardOrder(append([]int(nil), relativeOrder...), restHand)...)
+			for _, cardPower := range cardPowers {
+				if len(firstPower) > 0 {
+					cardPower = append(append([]int(nil), firstPower...), cardPower...)
+				}
+				plans = append(plans, opponentPlan{order: cardOrder, power: cardPower, weight: 1})
+			}
+		}
+		return plans
+	}
+	plans := make([]opponentPlan, 0, maxOpponentPlans)
+	for len(plans) < maxOpponentPlans {
+		plans = append(plans, opponentPlan{
+			order:  append(append([]int(nil), firstOrder...), convertCardOrder(rnd.Perm(numCards), restHand)...),
+			power:  append(append([]int(nil), firstPower...), randomPowerDistribution(numPowers, totalPower, rnd)...),
+			weight: 1,
+		})
+	}
+	return plans
 }
 
-func calcChromosomeFitness(chromosome chromosome, compHand Hand, userHand Hand) float32 {
-	var totalMatches, totalWins, numCards int
-	var winPercentage float32
-	for _, v := range userHand.cards {
-		if v.playable {
-			numCards++
+//randomPowerDistribution will split totalPower between numCards, every distribution has the same probability
+func randomPowerDistribution(numCards int, totalPower int, rnd *rand.Rand) []int {
+	//Select positions of numCards-1 separators between totalPower units
+	separators := rnd.Perm(totalPower + numCards - 1)[:numCards-1]
+	sort.Ints(separators)
+	power := make([]int, numCards)
+	previous := -1
+	for i, v := range separators {
+		power[i] = v - previous - 1
+		previous = v
+	}
+	power[numCards-1] = totalPower + numCards - 2 - previous
+	return power
+}
+
+//addBotFlags will add the flags for the number of go routines calculating the fitness, the parameters of the robust objectives, the solver mode and the search budget of the mcts bot
+func addBotFlags(flags *flag.FlagSet) {
+	flags.IntVar(&threadsNum, "threads", threadsNum, "number of goroutines to calculate the fitness of the ga bot plans")
+	flags.Var((*fractionFlag)(&riskWeight), "risk-weight", "weight of the worst case in the "+objectiveBlend+" objective of the ga bot, from 0 to 1")
+	flags.Var((*fractionFlag)(&robustQuantile), "quantile", "share of the worst opponent plans ignored by the "+objectiveQuantile+" objective of the ga bot, from 0 to 1")
+	flags.Var((*solverModeFlag)(&solverMode), "solver-mode", fmt.Sprint("how the solver bot evaluates the next turns, one of ", solverModeNames, ", "+solverModeNames[solverEquilibrium]+" is the game-theoretic value, but supports hands up to ", solverEquilibriumHands, " cards"))
+	flags.IntVar(&mctsIterations, "mcts-iterations", mctsIterations, "number of search iterations for every move of the mcts bot")
+	flags.DurationVar(&mctsTimeLimit, "mcts-time", mctsTimeLimit, "search time for every move of the mcts bot, overrides -mcts-iterations if it is not 0")
+}
+
+//calcChromosomesFitness will calculate the fitness of every chromosome for the objective with the pool of threadsNum workers, cutoff is passed to calcChromosomeFitness.
+//Every chromosome is calculated independently, so the result doesn't depend on the number of workers
+func calcChromosomesFitness(chromosomes []chromosome, compHand game.Hand, userHand game.Hand, plans []opponentPlan, seat int, objective string, cutoff float32) {
+	workersNum := threadsNum
+	if workersNum > len(chromosomes) {
+		workersNum = len(chromosomes)
+	}
+	if workersNum <= 1 {
+		for i, v := range chromosomes {
+			chromosomes[i].fitness = calcChromosomeFitness(v, compHand, userHand, plans, seat, objective, cutoff)
 		}
+		return
 	}
-	//Generate temp slice to store card order numbers
-	tempSlice := make([]int, numCards)
-	for i := range tempSlice {
-		tempSlice[i] = i
-	}
-	//Get all possible orders of the cards for the specific amount of cards
-	cardOrders := GetAllPermutations(tempSlice)
-
-	//Get all possible power combinations for numCards
-	maxAvailablePower := userHand.power
-	cardPowers := GetAllPermutationsForSum(numCards, maxAvailablePower)
-	Logger.Debug(compHand.cards)
-	Logger.Debug(userHand.cards)
-	//TODO: Skip incorrect variants of cardOrder, if we know user selected card
-	for _, cardOrder := range cardOrders {
-		Logger.Debug(cardOrder)
-		cardOrder = convertCardOrder(cardOrder, userHand)
-		Logger.Debug(cardOrder)
-		for _, cardPower := range cardPowers {
-			//Logger.Debug(cardPower)
-			totalMatches++
-			compHealth := compHand.health
-			userHealth := userHand.health
-			for i, v := range cardOrder {
-				//Logger.Debug(compHand.cards[chromosome.genes[i].order].value, chromosome.genes[i].power)
-				//Logger.Debug(userHand.cards[v].value, cardPower[i])
-				compAttack := compHand.cards[chromosome.genes[i].order].value * (chromosome.genes[i].power + 1)
-				userAttack := userHand.cards[v].value * (cardPower[i] + 1)
-				if compAttack > userAttack {
-					userHealth -= compHand.cards[chromosome.genes[i].order].damage
-				} else {
-					compHealth -= userHand.cards[v].damage
-				}
-				//Logger.Debug(userHealth, ":", compHealth)
 
-				if userHealth < 1 || compHealth < 1 {
-					break
-				}
+	jobs := make(chan int)
+	var workers sync.WaitGroup
+	for w := 0; w < workersNum; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			//Every worker writes only fitness of the received chromosome
+			for i := range jobs {
+				chromosomes[i].fitness = calcChromosomeFitness(chromosomes[i], compHand, userHand, plans, seat, objective, cutoff)
 			}
-			if userHealth < 1 || userHealth <= compHealth {
-				totalWins++
+		}()
+	}
+	for i := range chromosomes {
+		jobs <- i
+	}
+	close(jobs)
+	workers.Wait()
+}
+
+//calcChromosomeFitness will play the chromosome against every opponent plan and return the fitness of the results for the objective.
+//Robust objectives depend only on the worst games, so the chromosome is pruned as soon as the fitness is known to be below the cutoff,
+//fitness of the pruned chromosome is below the cutoff, but it can be above the real one
+func calcChromosomeFitness(chromosome chromosome, compHand game.Hand, userHand game.Hand, plans []opponentPlan, seat int, objective string, cutoff float32) float32 {
+	results := newOutcomes(compHand, userHand)
+	totalWeight := float64(0)
+	for _, plan := range plans {
+		totalWeight += float64(plan.weight)
+	}
+	quantile, robust := robustObjective(objective)
+	maxWorstWeight := float64(quantile) * totalWeight
+	weightBelowCutoff := float64(0)
+	Logger.Debug(compHand.Cards)
+	Logger.Debug(userHand.Cards)
+	for _, plan := range plans {
+		compHealth, userHealth := playPlan(chromosome, compHand, userHand, plan, seat)
+		health := results.add(compHealth, userHealth, userHand, plan.weight)
+		if robust && results.scale(float64(health)) < cutoff {
+			weightBelowCutoff += float64(plan.weight)
+			if weightBelowCutoff > maxWorstWeight {
+				break
 			}
 		}
 	}
-	Logger.Debug(totalMatches)
-	Logger.Debug(totalWins)
-	winPercentage = float32(totalWins) / float32(totalMatches)
-	return winPercentage
+	Logger.Debug(results)
+	return results.score(objective, totalWeight)
+}
+
+//playPlan will play the chromosome against the opponent plan and return the final health of the comp and the opponent
+func playPlan(chromosome chromosome, compHand game.Hand, userHand game.Hand, plan opponentPlan, seat int) (int, int) {
+	compHealth := compHand.Health
+	userHealth := userHand.Health
+	for i, v := range plan.order {
+		//Logger.Debug(compHand.Cards[chromosome.genes[i].order].Value, chromosome.genes[i].power)
+		//Logger.Debug(userHand.Cards[v].Value, plan.power[i])
+		compAttack := game.Attack(compHand.Cards[chromosome.genes[i].order], chromosome.genes[i].power)
+		userAttack := game.Attack(userHand.Cards[v], plan.power[i])
+		if game.WinsBattle(seat, compAttack, userAttack) {
+			userHealth -= compHand.Cards[chromosome.genes[i].order].Damage
+		} else {
+			compHealth -= userHand.Cards[v].Damage
+		}
+		//Logger.Debug(userHealth, ":", compHealth)
+
+		if userHealth < 1 || compHealth < 1 {
+			break
+		}
+	}
+	return compHealth, userHealth
+}
+
+//sortCounterPlans will sort the opponent plans from the worst for the chromosome, so the pruning of the similar chromosomes finds the counter plans early
+func sortCounterPlans(chromosome chromosome, compHand game.Hand, userHand game.Hand, plans []opponentPlan, seat int) {
+	healths := make([]int, len(plans))
+	order := make([]int, len(plans))
+	for i, plan := range plans {
+		compHealth, userHealth := playPlan(chromosome, compHand, userHand, plan, seat)
+		healths[i] = compHealth - userHealth
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return healths[order[i]] < healths[order[j]]
+	})
+	sortedPlans := make([]opponentPlan, len(plans))
+	for i, v := range order {
+		sortedPlans[i] = plans[v]
+	}
+	copy(plans, sortedPlans)
 }
 
 //convert relative card order (excluding played) to absolute order in hand
-func convertCardOrder(cardOrder []int, hand Hand) []int {
-	for i, v := range hand.cards {
-		if !v.playable {
+func convertCardOrder(cardOrder []int, hand game.Hand) []int {
+	for i, v := range hand.Cards {
+		if !v.Playable {
 			for j, w := range cardOrder {
 				Logger.Debug(i, v)
 				Logger.Debug(j, w)
@@ -442,35 +694,71 @@ func sortChromosomes(chromosomes []chromosome) {
 	})
 }
 
-func filterHand(hand Hand) Hand {
-	var newHand Hand
-	//Copying original hand
-	newHand.health = hand.health
-	newHand.power = hand.power
-	newHand.selectedCard = hand.selectedCard
-	newHand.selectedPower = hand.selectedPower
-	for i, v := range hand.cards {
-		if v.playable || i == hand.selectedCard {
-			newHand.cards = append(newHand.cards, v)
-		}
-	}
-	return newHand
-}
-
-//GetNextMove will return card number and power for the next comp move
-func GetNextMove(compHand Hand, userHand Hand) (int, int) {
+//evolvePlans will evolve the comp plans against all opponent plans and return them sorted by the fitness for the objective, seat is the comp index in the game.
+//Opponent plans are weighted by the opponent model, if it is not nil. Evolution stops after the current generation, if the context is done
+func evolvePlans(ctx context.Context, compHand game.Hand, userHand game.Hand, seat int, objective string, model *powerModel, rnd *rand.Rand) []chromosome {
 	//	var nextCardNumber, nextPower int
-	rand.Seed(time.Now().UnixNano())
-	newCompHand := filterHand(compHand)
-	newUserHand := filterHand(userHand)
-	Logger.Debug(newCompHand)
-	Logger.Debug(newUserHand)
-	population := generatePopulation(compHand)
-	calcChromosomesFitness(population.chromosomes, compHand, userHand)
+	Logger.Debug(compHand)
+	Logger.Debug(userHand)
+	population := generatePopulation(compHand, rnd)
+	//Opponent plans are the same for all chromosomes, so they are generated once per move
+	plans := opponentPlans(userHand, rnd)
+	weightPlans(plans, userHand, model)
+	calcChromosomesFitness(population.chromosomes, compHand, userHand, plans, seat, objective, 0)
 	sortChromosomes(population.chromosomes)
 
-	//calcChromosomeFitness(population.chromosomes[0], newCompHand, newUserHand)
+	//Evolve the population only if it doesn't contain all available variants already
+	if len(population.chromosomes) == maxPopulationSize {
+		bestFitness := population.chromosomes[0].fitness
+		staleGenerations := 0
+		for generation := 0; generation < generationsLimit && staleGenerations < plateauLimit && ctx.Err() == nil; generation++ {
+			var cutoff float32
+			if _, robust := robustObjective(objective); robust {
+				//Offspring below the worst elite can't replace it, so their exact fitness is not needed
+				cutoff = population.chromosomes[elitesNumber(len(population.chromosomes))-1].fitness
+				sortCounterPlans(population.chromosomes[0], compHand, userHand, plans, seat)
+			}
+			population = transmogrifyPopulation(population, compHand, rnd)
+			//Elites already have fitness calculated
+			calcChromosomesFitness(population.chromosomes[elitesNumber(len(population.chromosomes)):], compHand, userHand, plans, seat, objective, cutoff)
+			sortChromosomes(population.chromosomes)
+			Logger.Debug("generation =", generation, "best fitness =", population.chromosomes[0].fitness)
+			//Stop early if the best fitness is not improving
+			if population.chromosomes[0].fitness > bestFitness {
+				bestFitness = population.chromosomes[0].fitness
+				staleGenerations = 0
+			} else {
+				staleGenerations++
+			}
+		}
+	}
+
 	Logger.Debug(population)
 	Logger.Debug(population.chromosomes[0])
-	return population.chromosomes[0].genes[0].order, population.chromosomes[0].genes[0].power
+	return population.chromosomes
+}
+
+//selectPlan will return the plan to play from the sorted plans
+func selectPlan(chromosomes []chromosome, rnd *rand.Rand) chromosome {
+	if gameRules.Simultaneous {
+		return mixedChromosome(chromosomes, rnd)
+	}
+	return chromosomes[0]
+}
+
+//GetNextMove will return card number, power and the fitness of the plan for the next comp move, seat is the comp index in the game
+func GetNextMove(compHand game.Hand, userHand game.Hand, seat int, objective string, rnd *rand.Rand) (int, int, float32) {
+	bestChromosome := selectPlan(evolvePlans(context.Background(), compHand, userHand, seat, objective, nil, rnd), rnd)
+	return bestChromosome.genes[0].order, bestChromosome.genes[0].power, bestChromosome.fitness
+}
+
+//mixedChromosome will select random chromosome from the sorted chromosomes with fitness close to the best one.
+//Opponent plans are evaluated without knowledge of the comp move, which is true for simultaneous moves,
+//so the comp should mix equally good plans to stay unpredictable for the opponent
+func mixedChromosome(chromosomes []chromosome, rnd *rand.Rand) chromosome {
+	candidatesNum := 1
+	for candidatesNum < len(chromosomes) && chromosomes[candidatesNum].fitness >= chromosomes[0].fitness-mixedPlanMargin {
+		candidatesNum++
+	}
+	return chromosomes[rnd.Intn(candidatesNum)]
 }
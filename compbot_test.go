@@ -0,0 +1,464 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//testGAHand will create the hand with the number of cards and the power, played cards are not playable
+func testGAHand(cardsNum int, power int, played ...int) game.Hand {
+	hand := game.Hand{Health: 10, Power: power, SelectedCard: -1}
+	for i := 0; i < cardsNum; i++ {
+		hand.Cards = append(hand.Cards, game.Card{Value: i + 1, Damage: i + 1, Playable: true})
+	}
+	for _, v := range played {
+		hand.Cards[v].Playable = false
+	}
+	return hand
+}
+
+//checkPlan will report the chromosome, which doesn't play every playable card once or spends more than the hand power
+func checkPlan(t *testing.T, name string, chromosome chromosome, hand game.Hand) {
+	t.Helper()
+	var cards []int
+	totalPower := 0
+	for _, v := range chromosome.genes {
+		cards = append(cards, v.order)
+		totalPower += v.power
+		if v.power < 0 {
+			t.Errorf("%v: negative power in %v", name, chromosome.genes)
+		}
+	}
+	sort.Ints(cards)
+	var playableCards []int
+	for i, v := range hand.Cards {
+		if v.Playable {
+			playableCards = append(playableCards, i)
+		}
+	}
+	if len(cards) != len(playableCards) {
+		t.Fatalf("%v: %v genes for %v playable cards", name, len(cards), len(playableCards))
+	}
+	for i, v := range cards {
+		if v != playableCards[i] {
+			t.Errorf("%v: cards %v are not a permutation of the playable cards %v", name, cards, playableCards)
+			break
+		}
+	}
+	if totalPower > hand.Power {
+		t.Errorf("%v: total power %v is above the hand power %v in %v", name, totalPower, hand.Power, chromosome.genes)
+	}
+}
+
+//fitnessChromosomes will create the chromosomes with the fitness values and the single gene numbered by the index
+func fitnessChromosomes(fitness []float32) []chromosome {
+	chromosomes := make([]chromosome, len(fitness))
+	for i, v := range fitness {
+		chromosomes[i] = chromosome{genes: []gene{{order: i}}, fitness: v}
+	}
+	return chromosomes
+}
+
+func TestTourneySelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		fitness []float32
+		number  int
+		want    []int //indexes of the selected chromosomes
+	}{
+		{"best one", []float32{0.1, 0.7, 0.3}, 1, []int{1}},
+		{"best two without repeats", []float32{0.1, 0.7, 0.3, 0.5}, 2, []int{1, 3}},
+		{"zero fitness", []float32{0, 0, 0.2}, 1, []int{2}},
+		{"more than chromosomes", []float32{0.4, 0.6}, 3, []int{1, 0}},
+	}
+	savedSampleSize := tourneySampleSize
+	defer func() { tourneySampleSize = savedSampleSize }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			//Sample of all chromosomes always contains the best one
+			tourneySampleSize = len(tt.fitness)
+			for seed := int64(0); seed < 10; seed++ {
+				selected := tourneySelect(fitnessChromosomes(tt.fitness), tt.number, rand.New(rand.NewSource(seed)))
+				if len(selected) != len(tt.want) {
+					t.Fatalf("seed %v: selected %v chromosomes, want %v", seed, len(selected), len(tt.want))
+				}
+				for i, v := range selected {
+					if v.genes[0].order != tt.want[i] {
+						t.Errorf("seed %v: selected chromosome %v is %v, want %v", seed, i, v.genes[0].order, tt.want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTourneySelectSample(t *testing.T) {
+	//Best chromosome of the population wins every tournament it takes part in, so it should be selected most often
+	fitness := []float32{0.1, 0.2, 0.9, 0.3, 0.4, 0.5}
+	counts := make([]int, len(fitness))
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		counts[tourneySelect(fitnessChromosomes(fitness), 1, rnd)[0].genes[0].order]++
+	}
+	for i, v := range counts {
+		if i != 2 && v >= counts[2] {
+			t.Errorf("chromosome %v with fitness %v is selected %v times, best one only %v times", i, fitness[i], v, counts[2])
+		}
+	}
+	if counts[0] != 0 {
+		t.Errorf("worst chromosome is selected %v times, it can't win the tournament of %v", counts[0], tourneySampleSize)
+	}
+}
+
+func TestCalcGenesHash(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     []gene
+		b     []gene
+		equal bool
+	}{
+		{"same genes", []gene{{0, 1}, {1, 2}}, []gene{{0, 1}, {1, 2}}, true},
+		{"other order", []gene{{0, 1}, {1, 2}}, []gene{{1, 1}, {0, 2}}, false},
+		{"other power", []gene{{0, 1}, {1, 2}}, []gene{{0, 2}, {1, 1}}, false},
+		{"order and power are not mixed", []gene{{1, 2}}, []gene{{2, 1}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calcGenesHash(tt.a) == calcGenesHash(tt.b); got != tt.equal {
+				t.Errorf("hashes of %v and %v equal = %v, want %v", tt.a, tt.b, got, tt.equal)
+			}
+		})
+	}
+}
+
+func TestMutations(t *testing.T) {
+	tests := []struct {
+		name string
+		hand game.Hand
+	}{
+		{"two cards", testGAHand(2, 5)},
+		{"four cards", testGAHand(4, 12)},
+		{"eight cards with played ones", testGAHand(8, 20, 0, 3, 6)},
+		{"no power", testGAHand(5, 0)},
+	}
+	mutations := []struct {
+		name        string
+		mutate      func(chromosome, game.Hand, *rand.Rand) chromosome
+		keepsPowers bool //power of every card is the same after the mutation
+		keepsOrder  bool //card order is the same after the mutation
+	}{
+		{"swap", func(c chromosome, _ game.Hand, rnd *rand.Rand) chromosome { return swapMutation(c, rnd) }, false, false},
+		{"displacement", func(c chromosome, _ game.Hand, rnd *rand.Rand) chromosome { return displacementMutation(c, rnd) }, true, false},
+		{"power", func(c chromosome, hand game.Hand, rnd *rand.Rand) chromosome {
+			return powerMutation(c, hand.Power, rnd)
+		}, false, true},
+	}
+	for _, tt := range tests {
+		for _, m := range mutations {
+			t.Run(tt.name+"/"+m.name, func(t *testing.T) {
+				rnd := rand.New(rand.NewSource(1))
+				for i := 0; i < 200; i++ {
+					parent := generateChromosome(tt.hand, rnd)
+					child := m.mutate(copyChromosome(parent), tt.hand, rnd)
+					checkPlan(t, m.name, child, tt.hand)
+					parentPowers := make(map[int]int)
+					for j, v := range parent.genes {
+						parentPowers[v.order] = v.power
+						if m.keepsOrder && child.genes[j].order != v.order {
+							t.Fatalf("card order is changed from %v to %v", parent.genes, child.genes)
+						}
+					}
+					if m.keepsPowers {
+						for _, v := range child.genes {
+							if parentPowers[v.order] != v.power {
+								t.Fatalf("power of card %v is changed from %v to %v", v.order, parent.genes, child.genes)
+							}
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMutateChromosomes(t *testing.T) {
+	hand := testGAHand(6, 15, 1)
+	rnd := rand.New(rand.NewSource(2))
+	var parents []chromosome
+	for i := 0; i < 100; i++ {
+		parents = append(parents, generateChromosome(hand, rnd))
+	}
+	children := mutateChromosomes(parents, hand, rnd)
+	if len(children) != len(parents) {
+		t.Fatalf("%v children for %v parents", len(children), len(parents))
+	}
+	for _, v := range children {
+		checkPlan(t, "mutation", v, hand)
+	}
+}
+
+func TestCrossoverGenesOX1(t *testing.T) {
+	tests := []struct {
+		name string
+		hand game.Hand
+	}{
+		{"one card", testGAHand(1, 4)},
+		{"two cards", testGAHand(2, 5)},
+		{"four cards", testGAHand(4, 12)},
+		{"eight cards with played ones", testGAHand(8, 30, 2, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(3))
+			for i := 0; i < 200; i++ {
+				first := generateChromosome(tt.hand, rnd)
+				second := generateChromosome(tt.hand, rnd)
+				child := crossoverGenesOX1(first, second, tt.hand.Power, rnd)
+				checkPlan(t, "child", child, tt.hand)
+				//Child shouldn't share the genes with the parent
+				if len(child.genes) > 0 && &child.genes[0] == &first.genes[0] {
+					t.Fatal("child shares the genes with the first parent")
+				}
+			}
+		})
+	}
+}
+
+func TestCrossoverChromosomesOX1(t *testing.T) {
+	hand := testGAHand(5, 10)
+	rnd := rand.New(rand.NewSource(4))
+	parents := []chromosome{generateChromosome(hand, rnd), generateChromosome(hand, rnd)}
+	for i := 0; i < 100; i++ {
+		children := crossoverChromosomesOX1(parents, hand, rnd)
+		if len(children) != len(parents) {
+			t.Fatalf("%v children for %v parents", len(children), len(parents))
+		}
+		for _, v := range children {
+			checkPlan(t, "child", v, hand)
+		}
+	}
+}
+
+func TestRepairGenesPower(t *testing.T) {
+	tests := []struct {
+		name     string
+		powers   []int
+		maxPower int
+	}{
+		{"within budget", []int{1, 2, 3}, 6},
+		{"below budget", []int{0, 1, 0}, 6},
+		{"above budget", []int{5, 5, 5}, 6},
+		{"single powered gene", []int{0, 9, 0}, 2},
+		{"no budget", []int{3, 0, 4}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(5))
+			for i := 0; i < 50; i++ {
+				var c chromosome
+				originalPower := 0
+				for j, v := range tt.powers {
+					c.genes = append(c.genes, gene{order: j, power: v})
+					originalPower += v
+				}
+				repaired := repairGenesPower(c, tt.maxPower, rnd)
+				totalPower := 0
+				for j, v := range repaired.genes {
+					totalPower += v.power
+					if v.power < 0 || v.power > tt.powers[j] {
+						t.Fatalf("power of gene %v is changed from %v to %v", j, tt.powers[j], v.power)
+					}
+				}
+				want := originalPower
+				if want > tt.maxPower {
+					want = tt.maxPower
+				}
+				if totalPower != want {
+					t.Fatalf("total power %v after repair, want %v", totalPower, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOpponentPlans(t *testing.T) {
+	savedPlans := maxOpponentPlans
+	defer func() { maxOpponentPlans = savedPlans }()
+	tests := []struct {
+		name          string
+		hand          game.Hand
+		selectedCard  int
+		selectedPower int
+		maxPlans      int
+		wantPlans     int
+		wantPowers    int //number of different powers of the first card, 0 if it is not checked
+		wantPower     int //power spent by every plan
+	}{
+		//4! orders and C(6+3, 3) power distributions
+		{"no selected card", testGAHand(4, 6), -1, 0, 12000, 24 * 84, 7, 6},
+		//3! orders of the remaining cards and C(4+2, 2) distributions of the remaining power
+		{"visible power", testGAHand(4, 6), 2, 2, 12000, 6 * 15, 1, 6},
+		//Hidden power is distributed with the power of the remaining cards, C(6+3, 3) distributions
+		{"hidden power", testGAHand(4, 6), 2, game.HiddenPower, 12000, 6 * 84, 7, 6},
+		//Power left after the last card is lost
+		{"last card with the visible power", testGAHand(4, 6, 0, 1, 3), 2, 4, 12000, 1, 1, 4},
+		{"last card with the hidden power", testGAHand(4, 6, 0, 1, 3), 2, game.HiddenPower, 12000, 1, 1, 6},
+		{"sampled plans", testGAHand(4, 6), 2, game.HiddenPower, 50, 50, 0, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxOpponentPlans = tt.maxPlans
+			hand := tt.hand
+			hand.SelectedCard, hand.SelectedPower = tt.selectedCard, tt.selectedPower
+			plans := opponentPlans(hand, rand.New(rand.NewSource(1)))
+			if len(plans) != tt.wantPlans {
+				t.Fatalf("%v plans, want %v", len(plans), tt.wantPlans)
+			}
+			seen := make(map[string]bool)
+			firstPowers := make(map[int]bool)
+			for _, v := range plans {
+				genes := make([]gene, len(v.order))
+				for i := range v.order {
+					genes[i] = gene{order: v.order[i], power: v.power[i]}
+				}
+				checkPlan(t, fmt.Sprint(v.order, v.power), chromosome{genes: genes}, tt.hand)
+				totalPower := 0
+				for _, p := range v.power {
+					totalPower += p
+				}
+				if totalPower != tt.wantPower {
+					t.Errorf("plan %v %v spends %v power, want %v", v.order, v.power, totalPower, tt.wantPower)
+				}
+				if tt.selectedCard >= 0 && v.order[0] != tt.selectedCard {
+					t.Errorf("plan %v %v doesn't start with the selected card %v", v.order, v.power, tt.selectedCard)
+				}
+				if tt.selectedPower >= 0 && tt.selectedCard >= 0 && v.power[0] != tt.selectedPower {
+					t.Errorf("plan %v %v doesn't start with the selected power %v", v.order, v.power, tt.selectedPower)
+				}
+				firstPowers[v.power[0]] = true
+				key := fmt.Sprint(v.order, v.power)
+				//Sampled plans can repeat
+				if seen[key] && tt.maxPlans > tt.wantPlans {
+					t.Errorf("plan %v is returned twice", key)
+				}
+				seen[key] = true
+			}
+			if tt.wantPowers > 0 && len(firstPowers) != tt.wantPowers {
+				t.Errorf("first card has %v different powers, want %v", len(firstPowers), tt.wantPowers)
+			}
+		})
+	}
+}
+
+//testDeal will deal the hands of the default rules for the seed
+func testDeal(seed int64) [2]game.Hand {
+	return dealHands(dealRules{}, rand.New(rand.NewSource(seed)))
+}
+
+func TestGetNextMoveThreads(t *testing.T) {
+	savedRules, savedThreads := gameRules, threadsNum
+	defer func() { gameRules, threadsNum = savedRules, savedThreads }()
+	gameRules = game.DefaultRules
+	//Fitness of every chromosome doesn't depend on the workers, so the same seed should give the same move
+	for seed := int64(1); seed <= 3; seed++ {
+		hands := testDeal(seed)
+		var moves [][3]float32
+		for _, threads := range []int{1, 2, 4} {
+			threadsNum = threads
+			card, power, fitness := GetNextMove(hands[game.Comp], hands[game.User], game.Comp, objectiveWinDraw, rand.New(rand.NewSource(seed)))
+			moves = append(moves, [3]float32{float32(card), float32(power), fitness})
+		}
+		for i, v := range moves[1:] {
+			if v != moves[0] {
+				t.Errorf("seed %v: move %v with %v threads is different from the serial move %v", seed, v, 1<<uint(i+1), moves[0])
+			}
+		}
+	}
+}
+
+func TestPruningKeepsElites(t *testing.T) {
+	savedRules, savedQuantile := gameRules, robustQuantile
+	defer func() { gameRules, robustQuantile = savedRules, savedQuantile }()
+	gameRules = game.DefaultRules
+	tests := []struct {
+		name      string
+		objective string
+		quantile  float32
+	}{
+		{"maximin", objectiveMaximin, 0},
+		{"quantile 0.1", objectiveQuantile, 0.1},
+		{"quantile 0.5", objectiveQuantile, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			robustQuantile = tt.quantile
+			for seed := int64(1); seed <= 3; seed++ {
+				rnd := rand.New(rand.NewSource(seed))
+				hands := testDeal(seed)
+				compHand, userHand := hands[game.Comp], hands[game.User]
+				plans := opponentPlans(userHand, rnd)
+				//Weighted plans like with the opponent model
+				for i := range plans {
+					plans[i].weight = 0.5 + 1.5*rnd.Float32()
+				}
+				//Same steps as one generation of evolvePlans
+				population := generatePopulation(compHand, rnd)
+				calcChromosomesFitness(population.chromosomes, compHand, userHand, plans, game.Comp, tt.objective, 0)
+				sortChromosomes(population.chromosomes)
+				elitesNum := elitesNumber(len(population.chromosomes))
+				cutoff := population.chromosomes[elitesNum-1].fitness
+				sortCounterPlans(population.chromosomes[0], compHand, userHand, plans, game.Comp)
+				population = transmogrifyPopulation(population, compHand, rnd)
+
+				pruned := copyChromosomes(population.chromosomes)
+				calcChromosomesFitness(population.chromosomes[elitesNum:], compHand, userHand, plans, game.Comp, tt.objective, 0)
+				calcChromosomesFitness(pruned[elitesNum:], compHand, userHand, plans, game.Comp, tt.objective, cutoff)
+				for i := elitesNum; i < len(pruned); i++ {
+					exact, fitness := population.chromosomes[i].fitness, pruned[i].fitness
+					//Pruned chromosome can't replace the elite, other chromosomes should have the exact fitness
+					if (exact < cutoff && fitness >= cutoff) || (exact >= cutoff && fitness != exact) {
+						t.Errorf("seed %v: fitness of %v is %v with cutoff %v, exact fitness is %v", seed, pruned[i].genes, fitness, cutoff, exact)
+					}
+				}
+
+				//Elites of the next generation have the same fitness, so the chosen chromosome is the same
+				exactFitness := make(map[uint64]float32)
+				for _, v := range population.chromosomes {
+					exactFitness[calcChromosomeHash(v)] = v.fitness
+				}
+				sortChromosomes(population.chromosomes)
+				sortChromosomes(pruned)
+				for i := 0; i < elitesNum; i++ {
+					if got := exactFitness[calcChromosomeHash(pruned[i])]; got != population.chromosomes[i].fitness {
+						t.Errorf("seed %v: elite %v has exact fitness %v with pruning, %v without pruning", seed, i, got, population.chromosomes[i].fitness)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetNextMove(b *testing.B) {
+	savedRules, savedThreads := gameRules, threadsNum
+	defer func() { gameRules, threadsNum = savedRules, savedThreads }()
+	gameRules = game.DefaultRules
+	hands := testDeal(1)
+	//Parallel run uses all CPUs, but at least 2 goroutines to show the overhead on a single CPU
+	parallel := savedThreads
+	if parallel < 2 {
+		parallel = 2
+	}
+	for _, threads := range []int{1, parallel} {
+		b.Run(fmt.Sprint("threads=", threads), func(b *testing.B) {
+			threadsNum = threads
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				GetNextMove(hands[game.Comp], hands[game.User], game.Comp, objectiveWinDraw, rand.New(rand.NewSource(1)))
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//remoteGame is the game played on the server from the client perspective
+type remoteGame struct {
+	conn               *netConn
+	seat               int
+	player             Player
+	human              bool //human player needs pauses to see the battle results
+	committed          committedMove
+	opponentCommitment game.Commitment
+}
+
+//chooseMove will ask the player for the move using the table sent by the server
+func (r *remoteGame) chooseMove(hands [2]game.Hand) game.Move {
+	move := game.Move{Player: r.seat}
+	move.Card, move.Power = r.player.ChooseMove(State{Seat: r.seat, Hand: hands[r.seat], Opponent: hands[game.Opponent(r.seat)]})
+	return move
+}
+
+//handle will process the message from the server, done is true after the game result
+func (r *remoteGame) handle(message netMessage) (done bool, err error) {
+	if message.Type != msgError && message.Type != msgReveal && message.Hands == nil {
+		return false, fmt.Errorf("%q message without hands", message.Type)
+	}
+	switch message.Type {
+	case msgState:
+		drawTable(visibleHands(message.Hands[game.Comp], message.Hands[game.User]))
+		if message.ToMove != r.seat {
+			fmt.Fprintln(screen, "OPPONENT TURN")
+			return false, nil
+		}
+		move := r.chooseMove(*message.Hands)
+		return false, r.conn.send(netMessage{Type: msgMove, Seat: r.seat, Card: move.Card, Power: move.Power})
+
+	case msgCommit:
+		drawTable(visibleHands(message.Hands[game.Comp], message.Hands[game.User]))
+		r.committed.move = r.chooseMove(*message.Hands)
+		if r.committed.nonce, err = game.NewNonce(); err != nil {
+			return false, err
+		}
+		r.committed.commitment = game.Commit(r.committed.move, r.committed.nonce)
+		fmt.Fprintln(screen, "WAITING FOR THE OPPONENT")
+		return false, r.conn.send(netMessage{Type: msgCommit, Seat: r.seat, Commitment: encodeCommitment(r.committed.commitment)})
+
+	case msgReveal:
+		if r.opponentCommitment, err = decodeCommitment(message.Commitment); err != nil {
+			return false, err
+		}
+		move := r.committed.move
+		return false, r.conn.send(netMessage{Type: msgReveal, Seat: r.seat, Card: move.Card, Power: move.Power, Nonce: r.committed.nonce})
+
+	case msgBattle:
+		hands := message.Hands
+		if gameRules.Simultaneous {
+			//Server could change the opponent move after seeing ours, so check it against the opponent commitment
+			opponent := hands[game.Opponent(r.seat)]
+			move := game.Move{Player: game.Opponent(r.seat), Card: opponent.SelectedCard, Power: opponent.SelectedPower}
+			if err := r.opponentCommitment.Verify(move, message.Nonce); err != nil {
+				return false, err
+			}
+		}
+		if r.human {
+			drawTable(visibleHands(hands[game.Comp], hands[game.User]))
+			pause("Press 'Enter' for the turn results...")
+		}
+		drawBattle(hands[game.Comp], hands[game.User])
+		if r.human {
+			pause("Press 'Enter' to continue...")
+		}
+		return false, nil
+
+	case msgResult:
+		drawTable(visibleHands(message.Hands[game.Comp], message.Hands[game.User]))
+		if message.Text != "" {
+			fmt.Fprintln(screen, message.Text)
+		}
+		switch message.Winner {
+		case game.Draw:
+			fmt.Fprint(screen, "DRAW")
+		case r.seat:
+			fmt.Fprint(screen, "YOU WIN")
+		default:
+			fmt.Fprint(screen, "YOU LOSE")
+		}
+		return true, nil
+
+	case msgError:
+		//Incorrect move, server sends the state again
+		fmt.Fprintln(screen, clrBadMessage+message.Text+clrReset)
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected message %q", message.Type)
+}
+
+//runConnect will join the game on the server and play it in the terminal
+func runConnect(args []string) {
+	flags := flag.NewFlagSet("connect", flag.ExitOnError)
+	playerType := flags.String("player", playerHuman, playerUsage("player in the seat"))
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed for the bot decisions")
+	useTUI := flags.Bool("tui", true, "full screen terminal UI with the keyboard card selection, prompts are used if disabled or the input is not a terminal")
+	addBotFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kaart connect [flags] <addr>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := net.Dial("tcp", flags.Arg(0))
+	if err != nil {
+		Logger.Fatal(err)
+	}
+	defer conn.Close()
+	r := &remoteGame{conn: newNetConn(conn, 0), human: *playerType == playerHuman}
+	fmt.Println("Connected to", conn.RemoteAddr(), "waiting for the opponent...")
+
+	welcome, err := r.conn.receive(msgWelcome)
+	if err != nil {
+		Logger.Fatal(err)
+	}
+	if welcome.Rules == nil {
+		Logger.Fatal("server didn't send the rules")
+	}
+	if err := welcome.Rules.Validate(); err != nil {
+		Logger.Fatal(err)
+	}
+	//Client uses the server rules for the bots and the table
+	gameRules = *welcome.Rules
+	r.seat = welcome.Seat
+	if r.player, err = newPlayer(*playerType, rand.New(rand.NewSource(*seed))); err != nil {
+		Logger.Fatal(err)
+	}
+	if r.human && *useTUI {
+		if terminal, err = newTUI(); err != nil {
+			Logger.Debug("terminal UI is disabled: ", err)
+		}
+	}
+
+	for done := false; !done; {
+		var message netMessage
+		message, err = r.conn.receive(msgState, msgCommit, msgReveal, msgBattle, msgResult, msgError)
+		if err == nil {
+			done, err = r.handle(message)
+		}
+		if err != nil {
+			terminal.close()
+			Logger.Fatal(err)
+		}
+	}
+	terminal.close()
+	fmt.Printf("\nYou played the %v seat\n", seatNames[r.seat])
+}
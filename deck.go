@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//Dealer types
+const (
+	dealerRandom string = "random" //legacy dealer with random card value and damage
+	dealerDeck   string = "deck"   //cards are dealt from CardDB tiers
+)
+
+//tiersFlag is the number of cards from every CardDB tier in the hand, e.g. 1,1,1,1
+type tiersFlag []int
+
+//dealRules describes how the hands are dealt
+type dealRules struct {
+	dealer string
+	tiers  tiersFlag
+}
+
+//String will return tiers as comma separated list
+func (tiers *tiersFlag) String() string {
+	var values []string
+	for _, v := range *tiers {
+		values = append(values, strconv.Itoa(v))
+	}
+	return strings.Join(values, ",")
+}
+
+//Set will parse comma separated list of tiers
+func (tiers *tiersFlag) Set(value string) error {
+	var newTiers tiersFlag
+	for _, v := range strings.Split(value, ",") {
+		cardsNum, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("incorrect number of cards %q", v)
+		}
+		newTiers = append(newTiers, cardsNum)
+	}
+	*tiers = newTiers
+	return nil
+}
+
+//addDealFlags will add flags for the deal rules to the flag set
+func addDealFlags(flags *flag.FlagSet) *dealRules {
+	rules := &dealRules{dealer: dealerRandom}
+	flags.StringVar(&rules.dealer, "dealer", dealerRandom, "how to deal the hands: "+dealerRandom+" or "+dealerDeck)
+	flags.Var(&rules.tiers, "tiers", "number of cards from every card database tier for the "+dealerDeck+" dealer (default hand size spread evenly over the tiers)")
+	return rules
+}
+
+//validate will check that hands can be dealt with the rules, default tiers are set if they are not provided
+func (rules *dealRules) validate() error {
+	switch rules.dealer {
+	case dealerRandom:
+		return nil
+	case dealerDeck:
+	default:
+		return fmt.Errorf("unknown dealer %q", rules.dealer)
+	}
+	if rules.tiers == nil {
+		//Spread the hand evenly over the tiers, lower tiers get the remaining cards
+		for i := range CardDB {
+			cardsNum := gameRules.HandSize / len(CardDB)
+			if i < gameRules.HandSize%len(CardDB) {
+				cardsNum++
+			}
+			rules.tiers = append(rules.tiers, cardsNum)
+		}
+	}
+	if len(rules.tiers) != len(CardDB) {
+		return fmt.Errorf("number of tiers should be %v, got %v", len(CardDB), len(rules.tiers))
+	}
+	var totalCards int
+	for i, v := range rules.tiers {
+		//Both hands are dealt from the same deck
+		if v < 0 || 2*v > len(CardDB[i]) {
+			return fmt.Errorf("incorrect number of cards %v in tier %v, range is 0 .. %v", v, i+1, len(CardDB[i])/2)
+		}
+		totalCards += v
+	}
+	if totalCards != gameRules.HandSize {
+		return fmt.Errorf("total number of cards in tiers should be equal to hand size %v, got %v", gameRules.HandSize, totalCards)
+	}
+	return nil
+}
+
+//dealHands will deal comp and user hands according to the rules
+func dealHands(rules dealRules, rnd *rand.Rand) [2]game.Hand {
+	var hands [2]game.Hand
+	if rules.dealer == dealerDeck {
+		return dealDeckHands(rules.tiers, rnd)
+	}
+	hands[game.User] = initHand(rnd)
+	hands[game.Comp] = initHand(rnd)
+	return hands
+}
+
+//dealDeckHands will shuffle every CardDB tier and deal the cards from the top to both hands, one card per tier at a time
+func dealDeckHands(tiers []int, rnd *rand.Rand) [2]game.Hand {
+	var hands [2]game.Hand
+	for i := range hands {
+		hands[i].Health = gameRules.MaxHealth
+		hands[i].Power = gameRules.MaxPower
+		hands[i].SelectedCard = -1
+	}
+	for tier, cardsNum := range tiers {
+		deck := rnd.Perm(len(CardDB[tier]))
+		for j := 0; j < cardsNum; j++ {
+			for i := range hands {
+				cardData := CardDB[tier][deck[2*j+i]]
+				hands[i].Cards = append(hands[i].Cards, game.Card{
+					Name:     "Card " + strconv.Itoa(len(hands[i].Cards)),
+					Value:    cardData.Power,
+					Damage:   cardData.Damage,
+					Playable: true,
+				})
+			}
+		}
+	}
+	return hands
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+func TestDealDeckHands(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	tests := []struct {
+		name     string
+		handSize int
+		tiers    tiersFlag
+	}{
+		{"card from every tier", 4, tiersFlag{1, 1, 1, 1}},
+		{"low tiers", 4, tiersFlag{3, 1, 0, 0}},
+		{"high tiers", 6, tiersFlag{0, 0, 3, 3}},
+		{"default tiers", 6, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameRules = game.DefaultRules
+			gameRules.HandSize = tt.handSize
+			rules := dealRules{dealer: dealerDeck, tiers: tt.tiers}
+			if err := rules.validate(); err != nil {
+				t.Fatal(err)
+			}
+			for seed := int64(1); seed <= 20; seed++ {
+				hands := dealHands(rules, rand.New(rand.NewSource(seed)))
+				//Cards of every tier are dealt one after another, both hands are dealt from the same shuffled tier
+				used := make(map[[3]int]int)
+				for i, hand := range hands {
+					if hand.PlayableCards() != gameRules.HandSize || len(hand.Cards) != gameRules.HandSize {
+						t.Fatalf("seed %v: hand %v has %v playable cards of %v, want %v", seed, i, hand.PlayableCards(), len(hand.Cards), gameRules.HandSize)
+					}
+					if hand.Health != gameRules.MaxHealth || hand.Power != gameRules.MaxPower || hand.SelectedCard != -1 {
+						t.Errorf("seed %v: hand %v has health %v, power %v, selected card %v", seed, i, hand.Health, hand.Power, hand.SelectedCard)
+					}
+					card := 0
+					for tier, cardsNum := range rules.tiers {
+						for j := 0; j < cardsNum; j++ {
+							v := hand.Cards[card]
+							if !containsInt(cardTiers(v), tier) {
+								t.Errorf("seed %v: card %+v of hand %v is not from tier %v", seed, v, i, tier+1)
+							}
+							used[[3]int{tier, v.Value, v.Damage}]++
+							card++
+						}
+					}
+				}
+				for card, count := range used {
+					if count > 1 {
+						t.Errorf("seed %v: card %v is dealt %v times", seed, card, count)
+					}
+				}
+			}
+		})
+	}
+}
+
+//cardTiers will return the CardDB tiers containing the card
+func cardTiers(card game.Card) []int {
+	var tiers []int
+	for tier, cards := range CardDB {
+		for _, v := range cards {
+			if v.Power == card.Value && v.Damage == card.Damage {
+				tiers = append(tiers, tier)
+				break
+			}
+		}
+	}
+	return tiers
+}
+
+//containsInt will return true if the value is in the slice
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDealRulesValidate(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	tests := []struct {
+		name      string
+		handSize  int
+		rules     dealRules
+		wantErr   bool
+		wantTiers tiersFlag
+	}{
+		{"random dealer", 4, dealRules{dealer: dealerRandom}, false, nil},
+		{"unknown dealer", 4, dealRules{dealer: "shuffle"}, true, nil},
+		{"default tiers", 4, dealRules{dealer: dealerDeck}, false, tiersFlag{1, 1, 1, 1}},
+		{"default tiers for the larger hand", 6, dealRules{dealer: dealerDeck}, false, tiersFlag{2, 2, 1, 1}},
+		{"tiers of the hand size", 5, dealRules{dealer: dealerDeck, tiers: tiersFlag{2, 0, 0, 3}}, false, tiersFlag{2, 0, 0, 3}},
+		{"too few tiers", 4, dealRules{dealer: dealerDeck, tiers: tiersFlag{2, 2}}, true, nil},
+		{"too many tiers", 4, dealRules{dealer: dealerDeck, tiers: tiersFlag{1, 1, 1, 1, 0}}, true, nil},
+		{"tiers below the hand size", 4, dealRules{dealer: dealerDeck, tiers: tiersFlag{1, 1, 1, 0}}, true, nil},
+		{"tiers above the hand size", 4, dealRules{dealer: dealerDeck, tiers: tiersFlag{1, 1, 1, 2}}, true, nil},
+		{"negative tier", 4, dealRules{dealer: dealerDeck, tiers: tiersFlag{3, 2, 0, -1}}, true, nil},
+		//Both hands take the cards from the same tier, so a tier of 7 cards has enough cards for 3 cards in every hand
+		{"tier above the card database", 4, dealRules{dealer: dealerDeck, tiers: tiersFlag{4, 0, 0, 0}}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameRules = game.DefaultRules
+			gameRules.HandSize = tt.handSize
+			rules := tt.rules
+			err := rules.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(rules.tiers, tt.wantTiers) {
+				t.Errorf("tiers = %v, want %v", rules.tiers, tt.wantTiers)
+			}
+		})
+	}
+}
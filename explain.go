@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Explanation parameters
+var (
+	explainPlansNumber int = 0  //number of the best plans of the GA player to show after every battle, 0 to disable
+	hintCompletions    int = 20 //number of random plans for the remaining cards evaluated for every card and power in the hint
+	hintPowersNumber   int = 3  //number of the best powers shown for every card in the hint
+)
+
+//notesPlayer is the player who explains own moves, notes are shown below the battle results
+type notesPlayer interface {
+	takeNotes() string
+}
+
+//explainPlans will describe the best plans with their fitness for the objective, plans starting with the played move are marked with the asterisk
+func explainPlans(seat int, objective string, chromosomes []chromosome, played chromosome, plansNum int) string {
+	var notes strings.Builder
+	fmt.Fprintf(&notes, "%v best plans by %v (card+power), * starts with the played move:\n", seatNames[seat], objective)
+	for i, v := range chromosomes {
+		if i >= plansNum {
+			break
+		}
+		mark := " "
+		if v.genes[0] == played.genes[0] {
+			mark = "*"
+		}
+		fmt.Fprintf(&notes, "%v%6.1f%%", mark, v.fitness*100)
+		for _, w := range v.genes {
+			fmt.Fprintf(&notes, " %v+%v", w.order+1, w.power)
+		}
+		notes.WriteString("\n")
+	}
+	return notes.String()
+}
+
+//battleNotes will return the notes of both players since the last battle, notes are cleared, so they are shown once
+func battleNotes(players [2]Player) string {
+	var notes string
+	for _, v := range players {
+		if player, ok := v.(notesPlayer); ok {
+			notes += player.takeNotes()
+		}
+	}
+	return notes
+}
+
+//moveHints will estimate the win rate of every card and power for the next move of the player, rates of not playable cards are nil.
+//Every move is rated by the best of the evolved plans and random plans for the remaining cards starting with this move
+func moveHints(state State, rnd *rand.Rand) [][]float32 {
+	hand := state.Hand
+	plans := opponentPlans(state.Opponent, rnd)
+	rates := make([][]float32, len(hand.Cards))
+	var playableCards []int
+	for i, v := range hand.Cards {
+		if v.Playable {
+			playableCards = append(playableCards, i)
+			rates[i] = make([]float32, hand.Power+1)
+		}
+	}
+	//Last card has the single plan for every power
+	completions := hintCompletions
+	if len(playableCards) == 1 {
+		completions = 1
+	} else {
+		for _, v := range evolvePlans(context.Background(), hand, state.Opponent, state.Seat, objectiveWin, nil, rnd) {
+			first := v.genes[0]
+			if v.fitness > rates[first.order][first.power] {
+				rates[first.order][first.power] = v.fitness
+			}
+		}
+	}
+
+	var samples []chromosome
+	for i, card := range playableCards {
+		//Remaining cards are played after the first one
+		otherCards := make([]int, 0, len(playableCards)-1)
+		otherCards = append(otherCards, playableCards[:i]...)
+		otherCards = append(otherCards, playableCards[i+1:]...)
+		for power := 0; power <= hand.Power; power++ {
+			for j := 0; j < completions; j++ {
+				plan := chromosome{genes: []gene{{order: card, power: power}}}
+				if len(otherCards) > 0 {
+					otherPowers := randomPowerDistribution(len(otherCards), hand.Power-power, rnd)
+					for k, v := range rnd.Perm(len(otherCards)) {
+						plan.genes = append(plan.genes, gene{order: otherCards[v], power: otherPowers[k]})
+					}
+				}
+				samples = append(samples, plan)
+			}
+		}
+	}
+	calcChromosomesFitness(samples, hand, state.Opponent, plans, state.Seat, objectiveWin, 0)
+	for _, v := range samples {
+		first := v.genes[0]
+		if v.fitness > rates[first.order][first.power] {
+			rates[first.order][first.power] = v.fitness
+		}
+	}
+	return rates
+}
+
+//hintText will show the best powers of every playable card with the estimated win rates
+func hintText(rates [][]float32) string {
+	var hint strings.Builder
+	hint.WriteString("Estimated win rates:\n")
+	for i, v := range rates {
+		if v == nil {
+			continue
+		}
+		powers := make([]int, len(v))
+		for j := range powers {
+			powers[j] = j
+		}
+		//Prefer less power for the same win rate
+		sort.SliceStable(powers, func(a, b int) bool {
+			return v[powers[a]] > v[powers[b]]
+		})
+		if len(powers) > hintPowersNumber {
+			powers = powers[:hintPowersNumber]
+		}
+		fmt.Fprintf(&hint, "Card %v:", i+1)
+		for j, w := range powers {
+			if j > 0 {
+				hint.WriteString(",")
+			}
+			fmt.Fprintf(&hint, " power %v %.0f%%", w, v[w]*100)
+		}
+		hint.WriteString("\n")
+	}
+	return hint.String()
+}
+
+//moveHint will estimate the win rates of the player moves from the table visible to the human, hints use own random generator, so they don't change the game
+func moveHint(state State) string {
+	return hintText(moveHints(tableState(state), rand.New(rand.NewSource(time.Now().UnixNano()))))
+}
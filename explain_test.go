@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//selectedState will return the state of the player, whose opponent already selected the card with the power
+func selectedState(seat int, power int) State {
+	hand := game.Hand{Health: 10, Power: 6, SelectedCard: -1}
+	for i := 0; i < 3; i++ {
+		hand.Cards = append(hand.Cards, game.Card{Value: i + 2, Damage: 3 - i, Playable: true})
+	}
+	opponent := hand.Copy()
+	opponent.SelectedCard, opponent.SelectedPower = 1, power
+	return State{Seat: seat, Hand: hand, Opponent: opponent}
+}
+
+func TestTableState(t *testing.T) {
+	tests := []struct {
+		name      string
+		seat      int
+		hidden    bool
+		wantPower int
+	}{
+		{"user sees comp power", game.User, false, 4},
+		{"user sees no comp power in hidden mode", game.User, true, game.HiddenPower},
+		{"comp seat sees user power", game.Comp, false, 4},
+		{"comp seat sees no user power in hidden mode", game.Comp, true, game.HiddenPower},
+	}
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameRules.HiddenPower = tt.hidden
+			state := tableState(selectedState(tt.seat, 4))
+			if state.Opponent.SelectedCard != 1 || state.Opponent.SelectedPower != tt.wantPower {
+				t.Errorf("opponent selection %v with power %v, want card 1 with power %v", state.Opponent.SelectedCard, state.Opponent.SelectedPower, tt.wantPower)
+			}
+			if state.Hand.SelectedCard != -1 || state.Hand.Power != 6 {
+				t.Errorf("own hand is changed: %+v", state.Hand)
+			}
+		})
+	}
+}
+
+func TestMoveHintsHidePower(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	gameRules = game.DefaultRules
+	gameRules.HiddenPower = true
+	//Comp power is not shown to the user, so the hints for any comp power should be the same
+	var rates [][]float32
+	for power := 0; power <= 6; power += 3 {
+		powerRates := moveHints(tableState(selectedState(game.User, power)), rand.New(rand.NewSource(1)))
+		if rates != nil && !reflect.DeepEqual(rates, powerRates) {
+			t.Errorf("hints depend on the hidden comp power %v", power)
+		}
+		rates = powerRates
+	}
+}
+
+func TestBattleNotes(t *testing.T) {
+	savedExplain := explainPlansNumber
+	defer func() { explainPlansNumber = savedExplain }()
+	explainPlansNumber = 2
+	var players [2]Player
+	for i, v := range []string{playerGA, playerGreedy} {
+		player, err := newPlayer(v, rand.New(rand.NewSource(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		players[i] = player
+	}
+	state := selectedState(game.Comp, 2)
+	state.Opponent.SelectedCard = -1
+	players[game.Comp].ChooseMove(state)
+	notes := battleNotes(players)
+	if !strings.Contains(notes, "best plans") {
+		t.Errorf("notes of the ga player are missing: %q", notes)
+	}
+	if notes := battleNotes(players); notes != "" {
+		t.Errorf("notes are shown twice: %q", notes)
+	}
+}
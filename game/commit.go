@@ -0,0 +1,38 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+//NonceSize is the size of the random nonce hiding the committed move
+const NonceSize int = 16
+
+//Commitment is the hash of the move and the nonce, it binds the player to the move without revealing it
+type Commitment [sha256.Size]byte
+
+//NewNonce will return the random nonce for the commitment, it is taken from the crypto random generator to be unpredictable
+func NewNonce() ([]byte, error) {
+	nonce := make([]byte, NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+//Commit will return the commitment for the move and the nonce
+func Commit(move Move, nonce []byte) Commitment {
+	return sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%d:%x", move.Player, move.Card, move.Power, nonce)))
+}
+
+//Verify will check that the revealed move and nonce match the commitment
+func (commitment Commitment) Verify(move Move, nonce []byte) error {
+	if len(nonce) != NonceSize {
+		return fmt.Errorf("nonce size is %v, should be %v", len(nonce), NonceSize)
+	}
+	if Commit(move, nonce) != commitment {
+		return fmt.Errorf("move of player %v doesn't match the commitment", move.Player)
+	}
+	return nil
+}
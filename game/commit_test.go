@@ -0,0 +1,36 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestCommitVerify(t *testing.T) {
+	move := Move{Player: User, Card: 2, Power: 5}
+	nonce, err := NewNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment := Commit(move, nonce)
+	otherNonce := append([]byte{}, nonce...)
+	otherNonce[0] ^= 1
+	tests := []struct {
+		name    string
+		move    Move
+		nonce   []byte
+		wantErr bool
+	}{
+		{"same move and nonce", move, nonce, false},
+		{"other card", Move{Player: User, Card: 1, Power: 5}, nonce, true},
+		{"other power", Move{Player: User, Card: 2, Power: 4}, nonce, true},
+		{"other player", Move{Player: Comp, Card: 2, Power: 5}, nonce, true},
+		{"other nonce", move, otherNonce, true},
+		{"short nonce", move, nonce[:NonceSize-1], true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := commitment.Verify(tt.move, tt.nonce); (err != nil) != tt.wantErr {
+				t.Errorf("Verify error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+//Player indexes
+const (
+	//Comp is the index of the hand drawn at the top of the table
+	Comp int = 0
+	//User is the index of the hand drawn at the bottom of the table
+	User int = 1
+	//Draw is returned by Winner if both players finished with the same health
+	Draw int = -1
+)
+
+//HiddenPower is the selected power of the player, who committed the move, but didn't reveal the power yet
+const HiddenPower int = -1
+
+//Card is a single card in the hand
+type Card struct {
+	Value    int    `json:"value"`
+	Damage   int    `json:"damage"`
+	Name     string `json:"name"`
+	Playable bool   `json:"playable"`
+}
+
+//Hand is a struct to store player or comp hand
+type Hand struct {
+	Health        int    `json:"health"`
+	Power         int    `json:"power"`
+	SelectedCard  int    `json:"selected_card"`
+	SelectedPower int    `json:"selected_power"`
+	Active        bool   `json:"active"`
+	Cards         []Card `json:"cards"`
+}
+
+//Move is a card and power selected by the player for the turn
+type Move struct {
+	Player int
+	Card   int
+	Power  int
+}
+
+//Battle is a result of the single turn
+type Battle struct {
+	Hands   [2]Hand //hands with the selected cards before the damage was applied
+	Attacks [2]int  //total attack of every player
+	Winner  int     //index of the player who won the battle
+	Damage  int     //damage dealt to the other player
+}
+
+//Game is the state of the single game between two hands
+type Game struct {
+	hands      [2]Hand
+	firstMover int
+	toMove     int
+	battles    []Battle
+}
+
+//Copy will return a deep copy of the hand
+func (hand Hand) Copy() Hand {
+	newHand := hand
+	newHand.Cards = make([]Card, len(hand.Cards))
+	copy(newHand.Cards, hand.Cards)
+	return newHand
+}
+
+//HidePower will return a copy of the hand with the selected power hidden, if the card is selected
+func (hand Hand) HidePower() Hand {
+	newHand := hand.Copy()
+	if newHand.SelectedCard != -1 {
+		newHand.SelectedPower = HiddenPower
+	}
+	return newHand
+}
+
+//PlayableCards will return number of cards, which are not played yet
+func (hand Hand) PlayableCards() int {
+	var playableCards int
+	for _, v := range hand.Cards {
+		if v.Playable {
+			playableCards++
+		}
+	}
+	return playableCards
+}
+
+//Opponent will return index of the other player
+func Opponent(player int) int {
+	return 1 - player
+}
+
+//Attack will return total attack of the card boosted with power
+func Attack(card Card, power int) int {
+	return card.Value * (power + 1)
+}
+
+//BattleWinner will return index of the player who wins the battle. Comp wins the tie
+func BattleWinner(compAttack int, userAttack int) int {
+	if userAttack > compAttack {
+		return User
+	}
+	return Comp
+}
+
+//WinsBattle will return true if the player attack wins the battle against the opponent attack
+func WinsBattle(player int, attack int, opponentAttack int) bool {
+	if player == User {
+		return BattleWinner(opponentAttack, attack) == User
+	}
+	return BattleWinner(attack, opponentAttack) == Comp
+}
+
+//New will create a new game for two hands, firstMover selects the card first on the first turn
+func New(compHand Hand, userHand Hand, firstMover int) *Game {
+	var g Game
+	g.hands[Comp] = compHand.Copy()
+	g.hands[User] = userHand.Copy()
+	g.firstMover = firstMover
+	g.toMove = firstMover
+	for i := range g.hands {
+		g.hands[i].SelectedCard = -1
+		g.hands[i].Active = i == g.toMove
+	}
+	return &g
+}
+
+//Hand will return a copy of the player hand
+func (g *Game) Hand(player int) Hand {
+	return g.hands[player].Copy()
+}
+
+//ToMove will return index of the player who should select the card now
+func (g *Game) ToMove() int {
+	return g.toMove
+}
+
+//FirstMover will return index of the player who selects the card first in the current turn
+func (g *Game) FirstMover() int {
+	return g.firstMover
+}
+
+//Turn will return number of the current turn, starting from 0
+func (g *Game) Turn() int {
+	return len(g.battles)
+}
+
+//Battles will return results of all finished turns
+func (g *Game) Battles() []Battle {
+	return g.battles
+}
+
+//LastBattle will return result of the last finished turn
+func (g *Game) LastBattle() Battle {
+	return g.battles[len(g.battles)-1]
+}
+
+//LegalMoves will return all moves available to the player, or nil if it's not the player turn
+func (g *Game) LegalMoves(player int) []Move {
+	var moves []Move
+	if g.IsOver() || player != g.toMove {
+		return nil
+	}
+	hand := g.hands[player]
+	for i, v := range hand.Cards {
+		if !v.Playable {
+			continue
+		}
+		for power := 0; power <= hand.Power; power++ {
+			moves = append(moves, Move{Player: player, Card: i, Power: power})
+		}
+	}
+	return moves
+}
+
+//Apply will select card and power for the player. Battle is resolved after both players selected their cards
+func (g *Game) Apply(move Move) error {
+	if g.IsOver() {
+		return errors.New("game is over")
+	}
+	if move.Player != g.toMove {
+		return fmt.Errorf("player %v can't move now", move.Player)
+	}
+	hand := &g.hands[move.Player]
+	if move.Card < 0 || move.Card >= len(hand.Cards) {
+		return fmt.Errorf("incorrect card number %v, card number range is 0 .. %v", move.Card, len(hand.Cards)-1)
+	}
+	if !hand.Cards[move.Card].Playable {
+		return fmt.Errorf("card %v already played", move.Card)
+	}
+	if move.Power < 0 || move.Power > hand.Power {
+		return fmt.Errorf("incorrect power value %v, power value range is 0 .. %v", move.Power, hand.Power)
+	}
+	hand.SelectedCard = move.Card
+	hand.SelectedPower = move.Power
+
+	if move.Player == g.firstMover {
+		g.setToMove(Opponent(move.Player))
+		return nil
+	}
+	g.resolveBattle()
+	return nil
+}
+
+//IsOver will return true if one of the players has no health or all cards are played
+func (g *Game) IsOver() bool {
+	for _, v := range g.hands {
+		if v.Health < 1 || v.PlayableCards() == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//Winner will return index of the player with more health or Draw. Valid only after the game is over
+func (g *Game) Winner() int {
+	if g.hands[User].Health == g.hands[Comp].Health {
+		return Draw
+	}
+	if g.hands[User].Health < g.hands[Comp].Health {
+		return Comp
+	}
+	return User
+}
+
+func (g *Game) setToMove(player int) {
+	g.toMove = player
+	for i := range g.hands {
+		g.hands[i].Active = i == player
+	}
+}
+
+func (g *Game) resolveBattle() {
+	var battle Battle
+	for i := range g.hands {
+		battle.Hands[i] = g.hands[i].Copy()
+		battle.Attacks[i] = Attack(g.hands[i].Cards[g.hands[i].SelectedCard], g.hands[i].SelectedPower)
+	}
+	battle.Winner = BattleWinner(battle.Attacks[Comp], battle.Attacks[User])
+	battle.Damage = g.hands[battle.Winner].Cards[g.hands[battle.Winner].SelectedCard].Damage
+	g.hands[Opponent(battle.Winner)].Health -= battle.Damage
+
+	for i := range g.hands {
+		g.hands[i].Power -= g.hands[i].SelectedPower
+		g.hands[i].Cards[g.hands[i].SelectedCard].Playable = false
+		g.hands[i].SelectedCard = -1
+		g.hands[i].SelectedPower = 0
+	}
+	g.battles = append(g.battles, battle)
+
+	//Players take turns to select the card first
+	g.firstMover = Opponent(g.firstMover)
+	g.setToMove(g.firstMover)
+}
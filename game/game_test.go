@@ -0,0 +1,231 @@
+package game
+
+import (
+	"testing"
+)
+
+//testHand will create the hand with the cards of the values and damages
+func testHand(health int, power int, values []int, damages []int) Hand {
+	hand := Hand{Health: health, Power: power, SelectedCard: -1}
+	for i, v := range values {
+		hand.Cards = append(hand.Cards, Card{Value: v, Damage: damages[i], Playable: true})
+	}
+	return hand
+}
+
+func TestBattleWinner(t *testing.T) {
+	tests := []struct {
+		name       string
+		compAttack int
+		userAttack int
+		want       int
+	}{
+		{"comp stronger", 5, 3, Comp},
+		{"user stronger", 3, 5, User},
+		{"tie goes to comp", 4, 4, Comp},
+		{"zero tie goes to comp", 0, 0, Comp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BattleWinner(tt.compAttack, tt.userAttack); got != tt.want {
+				t.Errorf("BattleWinner(%v, %v) = %v, want %v", tt.compAttack, tt.userAttack, got, tt.want)
+			}
+			//WinsBattle should agree with BattleWinner from both sides
+			if got := WinsBattle(Comp, tt.compAttack, tt.userAttack); got != (tt.want == Comp) {
+				t.Errorf("WinsBattle(Comp, %v, %v) = %v", tt.compAttack, tt.userAttack, got)
+			}
+			if got := WinsBattle(User, tt.userAttack, tt.compAttack); got != (tt.want == User) {
+				t.Errorf("WinsBattle(User, %v, %v) = %v", tt.userAttack, tt.compAttack, got)
+			}
+		})
+	}
+}
+
+func TestLegalMoves(t *testing.T) {
+	g := New(testHand(10, 2, []int{1, 2, 3}, []int{1, 1, 1}), testHand(10, 1, []int{1, 2, 3}, []int{1, 1, 1}), Comp)
+	tests := []struct {
+		name   string
+		player int
+		want   int
+	}{
+		{"player to move has every card with every power", Comp, 3 * 3},
+		{"other player has no moves", User, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moves := g.LegalMoves(tt.player)
+			if len(moves) != tt.want {
+				t.Fatalf("LegalMoves(%v) returned %v moves, want %v", tt.player, len(moves), tt.want)
+			}
+			for _, v := range moves {
+				if err := New(g.Hand(Comp), g.Hand(User), Comp).Apply(v); err != nil {
+					t.Errorf("legal move %+v is rejected: %v", v, err)
+				}
+			}
+		})
+	}
+
+	//Played cards are not legal anymore
+	if err := g.Apply(Move{Player: Comp, Card: 0, Power: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Apply(Move{Player: User, Card: 2, Power: 0}); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range g.LegalMoves(g.ToMove()) {
+		if v.Card == 2 {
+			t.Errorf("played card is returned in %+v", v)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		moves   []Move
+		wantErr bool
+	}{
+		{"first mover moves", []Move{{Player: Comp, Card: 0, Power: 1}}, false},
+		{"second mover can't move first", []Move{{Player: User, Card: 0, Power: 0}}, true},
+		{"card number below range", []Move{{Player: Comp, Card: -1, Power: 0}}, true},
+		{"card number above range", []Move{{Player: Comp, Card: 2, Power: 0}}, true},
+		{"negative power", []Move{{Player: Comp, Card: 0, Power: -1}}, true},
+		{"power above the hand power", []Move{{Player: Comp, Card: 0, Power: 3}}, true},
+		{"same player can't move twice", []Move{{Player: Comp, Card: 0, Power: 0}, {Player: Comp, Card: 1, Power: 0}}, true},
+		{"played card can't be played again", []Move{{Player: Comp, Card: 0, Power: 0}, {Player: User, Card: 0, Power: 0}, {Player: User, Card: 0, Power: 0}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(testHand(10, 2, []int{1, 2}, []int{1, 1}), testHand(10, 2, []int{1, 2}, []int{1, 1}), Comp)
+			var err error
+			for _, v := range tt.moves {
+				if err = g.Apply(v); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apply error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyResolvesBattle(t *testing.T) {
+	tests := []struct {
+		name        string
+		compMove    Move
+		userMove    Move
+		wantWinner  int
+		wantHealths [2]int
+	}{
+		//Comp card 0 has value 2 and damage 3, user card 0 has value 3 and damage 4
+		{"comp wins with power", Move{Player: Comp, Card: 0, Power: 2}, Move{Player: User, Card: 0, Power: 0}, Comp, [2]int{10, 7}},
+		{"user wins with value", Move{Player: Comp, Card: 0, Power: 0}, Move{Player: User, Card: 0, Power: 0}, User, [2]int{6, 10}},
+		{"comp wins the tie", Move{Player: Comp, Card: 0, Power: 2}, Move{Player: User, Card: 1, Power: 2}, Comp, [2]int{10, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(testHand(10, 3, []int{2, 1}, []int{3, 1}), testHand(10, 3, []int{3, 2}, []int{4, 1}), Comp)
+			if err := g.Apply(tt.compMove); err != nil {
+				t.Fatal(err)
+			}
+			if g.Turn() != 0 || g.ToMove() != User {
+				t.Fatalf("battle is resolved before the second move, turn %v, to move %v", g.Turn(), g.ToMove())
+			}
+			if err := g.Apply(tt.userMove); err != nil {
+				t.Fatal(err)
+			}
+			if g.Turn() != 1 {
+				t.Fatalf("turn is %v after both moves, want 1", g.Turn())
+			}
+			battle := g.LastBattle()
+			if battle.Winner != tt.wantWinner {
+				t.Errorf("winner = %v, want %v", battle.Winner, tt.wantWinner)
+			}
+			for i := range tt.wantHealths {
+				if g.Hand(i).Health != tt.wantHealths[i] {
+					t.Errorf("health of player %v = %v, want %v", i, g.Hand(i).Health, tt.wantHealths[i])
+				}
+			}
+			if g.Hand(Comp).Power != 3-tt.compMove.Power || g.Hand(User).Power != 3-tt.userMove.Power {
+				t.Errorf("power is not spent: comp %v, user %v", g.Hand(Comp).Power, g.Hand(User).Power)
+			}
+			//Players take turns to select the card first
+			if g.FirstMover() != User || g.ToMove() != User {
+				t.Errorf("first mover = %v, to move = %v, want the user", g.FirstMover(), g.ToMove())
+			}
+		})
+	}
+}
+
+func TestIsOverAndWinner(t *testing.T) {
+	tests := []struct {
+		name        string
+		compHand    Hand
+		userHand    Hand
+		wantOver    bool
+		wantWinner  int
+		playedCards bool
+	}{
+		{"both alive with cards", testHand(5, 0, []int{1}, []int{1}), testHand(5, 0, []int{1}, []int{1}), false, Draw, false},
+		{"comp has no health", testHand(0, 0, []int{1}, []int{1}), testHand(5, 0, []int{1}, []int{1}), true, User, false},
+		{"user has negative health", testHand(3, 0, []int{1}, []int{1}), testHand(-2, 0, []int{1}, []int{1}), true, Comp, false},
+		{"all cards played with equal health", testHand(4, 0, []int{1}, []int{1}), testHand(4, 0, []int{1}, []int{1}), true, Draw, true},
+		{"all cards played with more comp health", testHand(4, 0, []int{1}, []int{1}), testHand(3, 0, []int{1}, []int{1}), true, Comp, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.playedCards {
+				tt.compHand.Cards[0].Playable = false
+				tt.userHand.Cards[0].Playable = false
+			}
+			g := New(tt.compHand, tt.userHand, Comp)
+			if got := g.IsOver(); got != tt.wantOver {
+				t.Fatalf("IsOver() = %v, want %v", got, tt.wantOver)
+			}
+			if tt.wantOver {
+				if got := g.Winner(); got != tt.wantWinner {
+					t.Errorf("Winner() = %v, want %v", got, tt.wantWinner)
+				}
+				if err := g.Apply(Move{Player: Comp}); err == nil {
+					t.Error("move is applied after the game is over")
+				}
+			}
+		})
+	}
+}
+
+func TestRulesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(rules *Rules)
+		wantErr bool
+	}{
+		{"default rules", func(rules *Rules) {}, false},
+		{"largest values", func(rules *Rules) {
+			rules.HandSize, rules.MaxHealth, rules.MaxPower, rules.MaxRank, rules.MaxDamage = MaxHandSize, MaxRulesValue, MaxRulesValue, MaxRulesValue, MaxRulesValue
+		}, false},
+		{"single card", func(rules *Rules) { rules.HandSize = 1 }, true},
+		{"too many cards", func(rules *Rules) { rules.HandSize = MaxHandSize + 1 }, true},
+		{"no health", func(rules *Rules) { rules.MaxHealth = 0 }, true},
+		{"too much health", func(rules *Rules) { rules.MaxHealth = MaxRulesValue + 1 }, true},
+		{"negative power", func(rules *Rules) { rules.MaxPower = -1 }, true},
+		{"too much power", func(rules *Rules) { rules.MaxPower = MaxRulesValue + 1 }, true},
+		{"zero rank", func(rules *Rules) { rules.MinRank = 0 }, true},
+		{"min rank above max rank", func(rules *Rules) { rules.MinRank = rules.MaxRank + 1 }, true},
+		{"too big rank", func(rules *Rules) { rules.MaxRank, rules.MaxDamage = MaxRulesValue+1, MaxRulesValue+1 }, true},
+		{"negative damage", func(rules *Rules) { rules.MinDamage = -1 }, true},
+		{"min damage above max damage", func(rules *Rules) { rules.MinDamage = rules.MaxDamage + 1 }, true},
+		{"too big damage", func(rules *Rules) { rules.MaxDamage = MaxRulesValue + 1 }, true},
+		{"max damage below max rank", func(rules *Rules) { rules.MaxDamage = rules.MaxRank - 1 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := DefaultRules
+			tt.change(&rules)
+			if err := rules.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
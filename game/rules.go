@@ -0,0 +1,72 @@
+package game
+
+import "fmt"
+
+//Limits for the rules, bots keep health and power in int8 and card sets in uint8 bit masks
+const (
+	//MaxHandSize is the maximum number of cards in the hand
+	MaxHandSize int = 8
+	//MaxRulesValue is the maximum health and power of the player, and the maximum value and damage of the card, the table has two digits for them
+	MaxRulesValue int = 99
+)
+
+//Rules are the parameters of the game
+type Rules struct {
+	//HandSize is amount of cards every player should have
+	HandSize int `json:"hand_size"`
+	//MaxHealth is the starting health of every player
+	MaxHealth int `json:"health"`
+	//MaxPower is the maximum power available to the player
+	MaxPower int `json:"power"`
+	//MinRank and MaxRank are the card value range for the random dealer
+	MinRank int `json:"min_rank"`
+	MaxRank int `json:"max_rank"`
+	//MinDamage and MaxDamage are the card damage range for the random dealer
+	MinDamage int `json:"min_damage"`
+	MaxDamage int `json:"max_damage"`
+	//HiddenPower hides the power of the first mover from the second mover until the battle
+	HiddenPower bool `json:"hidden_power"`
+	//Simultaneous makes both players commit the moves without seeing the opponent selection
+	Simultaneous bool `json:"simultaneous"`
+}
+
+//DefaultRules are the rules used if no rules file is provided
+var DefaultRules = Rules{
+	HandSize:  4,
+	MaxHealth: 12,
+	MaxPower:  12,
+	MinRank:   2,
+	MaxRank:   8,
+	MinDamage: 1,
+	MaxDamage: 8,
+}
+
+//Validate will check that the rules are consistent
+func (rules Rules) Validate() error {
+	if rules.HandSize < 2 || rules.HandSize > MaxHandSize {
+		return fmt.Errorf("hand_size %v is out of range 2 .. %v", rules.HandSize, MaxHandSize)
+	}
+	if rules.MaxHealth < 1 || rules.MaxHealth > MaxRulesValue {
+		return fmt.Errorf("health %v is out of range 1 .. %v", rules.MaxHealth, MaxRulesValue)
+	}
+	if rules.MaxPower < 0 || rules.MaxPower > MaxRulesValue {
+		return fmt.Errorf("power %v is out of range 0 .. %v", rules.MaxPower, MaxRulesValue)
+	}
+	if rules.MinRank < 1 || rules.MinRank > rules.MaxRank {
+		return fmt.Errorf("min_rank %v should be at least 1 and not bigger than max_rank %v", rules.MinRank, rules.MaxRank)
+	}
+	if rules.MaxRank > MaxRulesValue {
+		return fmt.Errorf("max_rank %v should not be bigger than %v", rules.MaxRank, MaxRulesValue)
+	}
+	if rules.MinDamage < 0 || rules.MinDamage > rules.MaxDamage {
+		return fmt.Errorf("min_damage %v should be at least 0 and not bigger than max_damage %v", rules.MinDamage, rules.MaxDamage)
+	}
+	if rules.MaxDamage > MaxRulesValue {
+		return fmt.Errorf("max_damage %v should not be bigger than %v", rules.MaxDamage, MaxRulesValue)
+	}
+	//Random dealer rolls damage in range up to MaxDamage-value, so every card value should fit
+	if rules.MaxDamage < rules.MaxRank {
+		return fmt.Errorf("max_damage %v should be at least max_rank %v", rules.MaxDamage, rules.MaxRank)
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"strconv"
@@ -10,19 +12,8 @@ import (
 	"time"
 
 	"github.com/zerobugdebug/go-log"
-)
-
-//Game constants
-const (
-	//HandSize is amount of cards every player should have
-	HandSize int = 4
-	//MaxPower is the maximum power available to the player
-	MaxPower  int = 12
-	maxHealth int = 12
-	maxRank   int = 8
-	minRank   int = 2
-	maxDamage int = 8
-	minDamage int = 1
+	"github.com/zerobugdebug/kaart/game"
+	"golang.org/x/term"
 )
 
 //Color constants
@@ -43,263 +34,342 @@ const (
 	clrBadMessage            string = "\033[31m"
 )
 
-type card struct {
-	value    int
-	damage   int
-	name     string
-	playable bool
-}
+//seatNames are the names of the comp and user seats for the messages
+var seatNames = [2]string{"COMP", "USER"}
 
-//Hand is a struct to store player or comp hand
-type Hand struct {
-	health        int
-	power         int
-	selectedCard  int
-	selectedPower int
-	active        bool
-	cards         []card
-}
+//clearScreen is the escape sequence to clear the terminal and move the cursor to the top left corner
+const clearScreen string = "\033[H\033[2J"
+
+//minTableCards is the number of cards fitting into the narrowest table frame, frame is widened for larger hands
+const minTableCards int = 4
 
 //Logger is a default log adapter
 var Logger = log.New(os.Stdout).WithoutDebug()
 
-func initHand() Hand {
-	var tmpHand Hand
-	var tmpCard card
+//screen is the output for the table drawing, terminal UI replaces it to draw in the raw mode
+var screen io.Writer = os.Stdout
+
+//initHand will deal random cards, last card takes the value remaining to the (HandSize-1)*MaxRank+1 total to balance both players
+func initHand(rnd *rand.Rand) game.Hand {
+	var tmpHand game.Hand
+	var tmpCard game.Card
 	var totalValue int
-	maxTotalValue := (HandSize-1)*maxRank + 1
-	tmpHand.health = maxHealth
-	tmpHand.power = MaxPower
-	tmpHand.selectedCard = -1
-	tmpHand.cards = make([]card, HandSize-1)
-	for i := range tmpHand.cards {
-		tmpCard.name = "Card " + strconv.Itoa(i)
-		tmpCard.playable = true
-		tmpCard.value = rand.Intn(maxRank-minRank+1) + minRank
-		tmpCard.damage = rand.Intn(maxDamage-tmpCard.value+1) + minDamage
-		totalValue += tmpCard.value
-		tmpHand.cards[i] = tmpCard
+	maxTotalValue := (gameRules.HandSize-1)*gameRules.MaxRank + 1
+	tmpHand.Health = gameRules.MaxHealth
+	tmpHand.Power = gameRules.MaxPower
+	tmpHand.SelectedCard = -1
+	tmpHand.Cards = make([]game.Card, gameRules.HandSize-1)
+	for i := range tmpHand.Cards {
+		tmpCard.Name = "Card " + strconv.Itoa(i)
+		tmpCard.Playable = true
+		tmpCard.Value = rnd.Intn(gameRules.MaxRank-gameRules.MinRank+1) + gameRules.MinRank
+		tmpCard.Damage = rnd.Intn(gameRules.MaxDamage-tmpCard.Value+1) + gameRules.MinDamage
+		totalValue += tmpCard.Value
+		tmpHand.Cards[i] = tmpCard
 	}
 	//Add last card with remaining power to balance total power on both players
-	tmpCard.name = "Card 3"
-	tmpCard.playable = true
-	tmpCard.value = (maxTotalValue - totalValue) + rand.Intn(minRank)
-	if tmpCard.value > maxRank {
-		tmpCard.value = maxRank
-	}
-	tmpCard.damage = rand.Intn(maxDamage-tmpCard.value+1) + minDamage
-	tmpHand.cards = append(tmpHand.cards, tmpCard)
+	tmpCard.Name = "Card " + strconv.Itoa(gameRules.HandSize-1)
+	tmpCard.Playable = true
+	tmpCard.Value = (maxTotalValue - totalValue) + rnd.Intn(gameRules.MinRank)
+	if tmpCard.Value > gameRules.MaxRank {
+		tmpCard.Value = gameRules.MaxRank
+	}
+	tmpCard.Damage = rnd.Intn(gameRules.MaxDamage-tmpCard.Value+1) + gameRules.MinDamage
+	tmpHand.Cards = append(tmpHand.Cards, tmpCard)
 	return tmpHand
 }
 
-func drawHand(hand Hand) {
-	//fmt.Println("┌────┬" + strings.Repeat("─", 15) + "┬────┐")
+//tableCards will return the number of cards fitting into the table frame for both hands
+func tableCards(firstHand game.Hand, secondHand game.Hand) int {
+	cardsNum := minTableCards
+	if len(firstHand.Cards) > cardsNum {
+		cardsNum = len(firstHand.Cards)
+	}
+	if len(secondHand.Cards) > cardsNum {
+		cardsNum = len(secondHand.Cards)
+	}
+	return cardsNum
+}
+
+//frameWidth will return the inner width of the frame with cardsNum cards, every card is 4 characters wide
+func frameWidth(cardsNum int) int {
+	return 4*cardsNum + 12
+}
+
+//spaces will return the padding of n spaces, text wider than the frame gets no padding
+func spaces(n int) string {
+	if n < 0 {
+		return ""
+	}
+	return strings.Repeat(" ", n)
+}
 
-	fmt.Println("┌────────────────────────────┐")
-	fmt.Print("│      ")
-	for i, v := range hand.cards {
-		if hand.selectedCard == i {
-			fmt.Print(clrSelectedCard + "┌──┐" + clrReset)
-		} else if v.playable {
-			fmt.Print(clrPlayableCard + "┌──┐" + clrReset)
+func drawHand(hand game.Hand, cardsNum int) {
+	//fmt.Println("┌────┬" + strings.Repeat("─", 15) + "┬────┐")
+	//Center cards in the frame, if hand has less cards than fits into the frame
+	padding := strings.Repeat(" ", 6+2*(cardsNum-len(hand.Cards)))
+
+	fmt.Fprintln(screen, "┌"+strings.Repeat("─", frameWidth(cardsNum))+"┐")
+	fmt.Fprint(screen, "│"+padding)
+	for i, v := range hand.Cards {
+		if hand.SelectedCard == i {
+			fmt.Fprint(screen, clrSelectedCard+"┌──┐"+clrReset)
+		} else if v.Playable {
+			fmt.Fprint(screen, clrPlayableCard+"┌──┐"+clrReset)
 		} else {
-			fmt.Print(clrNonPlayableCard + "┌──┐" + clrReset)
+			fmt.Fprint(screen, clrNonPlayableCard+"┌──┐"+clrReset)
 		}
 	}
-	fmt.Println("      │")
-
-	fmt.Print("│      ")
-	for i, v := range hand.cards {
-		if hand.selectedCard == i {
-			fmt.Print(clrSelectedCard + "│" + clrReset)
-			fmt.Printf(clrSelectedCard+clrPlayableCardPower+"%2d"+clrReset, v.value)
-			fmt.Print(clrSelectedCard + "│" + clrReset)
-		} else if v.playable {
-			fmt.Print(clrPlayableCard + "│" + clrReset)
-			fmt.Printf(clrPlayableCard+clrPlayableCardPower+"%2d"+clrReset, v.value)
-			fmt.Print(clrPlayableCard + "│" + clrReset)
+	fmt.Fprintln(screen, padding+"│")
+
+	fmt.Fprint(screen, "│"+padding)
+	for i, v := range hand.Cards {
+		if hand.SelectedCard == i {
+			fmt.Fprint(screen, clrSelectedCard+"│"+clrReset)
+			fmt.Fprintf(screen, clrSelectedCard+clrPlayableCardPower+"%2d"+clrReset, v.Value)
+			fmt.Fprint(screen, clrSelectedCard+"│"+clrReset)
+		} else if v.Playable {
+			fmt.Fprint(screen, clrPlayableCard+"│"+clrReset)
+			fmt.Fprintf(screen, clrPlayableCard+clrPlayableCardPower+"%2d"+clrReset, v.Value)
+			fmt.Fprint(screen, clrPlayableCard+"│"+clrReset)
 		} else {
-			fmt.Print(clrNonPlayableCard + "│" + clrReset)
-			fmt.Printf(clrNonPlayableCard+clrNonPlayableCardPower+"%2d"+clrReset, v.value)
-			fmt.Print(clrNonPlayableCard + "│" + clrReset)
+			fmt.Fprint(screen, clrNonPlayableCard+"│"+clrReset)
+			fmt.Fprintf(screen, clrNonPlayableCard+clrNonPlayableCardPower+"%2d"+clrReset, v.Value)
+			fmt.Fprint(screen, clrNonPlayableCard+"│"+clrReset)
 		}
 	}
-	fmt.Println("      │")
-
-	fmt.Printf("│  "+clrHealth+"%2d  "+clrReset, hand.health)
-	for i, v := range hand.cards {
-		if hand.selectedCard == i {
-			fmt.Print(clrSelectedCard + "├──┤" + clrReset)
-		} else if v.playable {
-			fmt.Print(clrPlayableCard + "├──┤" + clrReset)
+	fmt.Fprintln(screen, padding+"│")
+
+	//Dead player has zero health, so the health fits into two digits
+	fmt.Fprintf(screen, "│"+padding[4:]+clrHealth+"%2d  "+clrReset, remainingHealth(hand))
+	for i, v := range hand.Cards {
+		if hand.SelectedCard == i {
+			fmt.Fprint(screen, clrSelectedCard+"├──┤"+clrReset)
+		} else if v.Playable {
+			fmt.Fprint(screen, clrPlayableCard+"├──┤"+clrReset)
 		} else {
-			fmt.Print(clrNonPlayableCard + "├──┤" + clrReset)
+			fmt.Fprint(screen, clrNonPlayableCard+"├──┤"+clrReset)
 		}
 	}
-	fmt.Printf("  "+clrPower+"%2d"+clrReset+"  │\n", hand.power)
-
-	fmt.Print("│      ")
-	for i, v := range hand.cards {
-		if hand.selectedCard == i {
-			fmt.Print(clrSelectedCard + "│" + clrReset)
-			fmt.Printf(clrSelectedCard+clrPlayableCardDamage+"%2d"+clrReset, v.damage)
-			fmt.Print(clrSelectedCard + "│" + clrReset)
-		} else if v.playable {
-			fmt.Print(clrPlayableCard + "│" + clrReset)
-			fmt.Printf(clrPlayableCard+clrPlayableCardDamage+"%2d"+clrReset, v.damage)
-			fmt.Print(clrPlayableCard + "│" + clrReset)
+	fmt.Fprintf(screen, "  "+clrPower+"%2d"+clrReset+padding[4:]+"│\n", hand.Power)
+
+	fmt.Fprint(screen, "│"+padding)
+	for i, v := range hand.Cards {
+		if hand.SelectedCard == i {
+			fmt.Fprint(screen, clrSelectedCard+"│"+clrReset)
+			fmt.Fprintf(screen, clrSelectedCard+clrPlayableCardDamage+"%2d"+clrReset, v.Damage)
+			fmt.Fprint(screen, clrSelectedCard+"│"+clrReset)
+		} else if v.Playable {
+			fmt.Fprint(screen, clrPlayableCard+"│"+clrReset)
+			fmt.Fprintf(screen, clrPlayableCard+clrPlayableCardDamage+"%2d"+clrReset, v.Damage)
+			fmt.Fprint(screen, clrPlayableCard+"│"+clrReset)
 		} else {
-			fmt.Print(clrNonPlayableCard + "│" + clrReset)
-			fmt.Printf(clrNonPlayableCard+clrNonPlayableCardDamage+"%2d"+clrReset, v.damage)
-			fmt.Print(clrNonPlayableCard + "│" + clrReset)
+			fmt.Fprint(screen, clrNonPlayableCard+"│"+clrReset)
+			fmt.Fprintf(screen, clrNonPlayableCard+clrNonPlayableCardDamage+"%2d"+clrReset, v.Damage)
+			fmt.Fprint(screen, clrNonPlayableCard+"│"+clrReset)
 		}
 	}
-	fmt.Println("      │")
-
-	fmt.Print("│      ")
-	for i, v := range hand.cards {
-		if hand.selectedCard == i {
-			fmt.Print(clrSelectedCard + "└──┘" + clrReset)
-		} else if v.playable {
-			fmt.Print(clrPlayableCard + "└──┘" + clrReset)
+	fmt.Fprintln(screen, padding+"│")
+
+	fmt.Fprint(screen, "│"+padding)
+	for i, v := range hand.Cards {
+		if hand.SelectedCard == i {
+			fmt.Fprint(screen, clrSelectedCard+"└──┘"+clrReset)
+		} else if v.Playable {
+			fmt.Fprint(screen, clrPlayableCard+"└──┘"+clrReset)
 		} else {
-			fmt.Print(clrNonPlayableCard + "└──┘" + clrReset)
+			fmt.Fprint(screen, clrNonPlayableCard+"└──┘"+clrReset)
 		}
 	}
-	fmt.Println("      │")
-	fmt.Println("└────────────────────────────┘")
+	fmt.Fprintln(screen, padding+"│")
+	fmt.Fprintln(screen, "└"+strings.Repeat("─", frameWidth(cardsNum))+"┘")
 	/*
 		fmt.Println("┌────┬───────────────┬────┐")
-		for _, v := range hand.cards {
-			if v.playable {
-				fmt.Printf("\033[32m%2d\033[0m │", v.value)
+		for _, v := range hand.Cards {
+			if v.Playable {
+				fmt.Printf("\033[32m%2d\033[0m │", v.Value)
 			} else {
-				fmt.Printf("\033[37m%2d\033[0m │", v.value)
+				fmt.Printf("\033[37m%2d\033[0m │", v.Value)
 			}
 		}
-		fmt.Printf("│"+clrHealth+"%3d"+clrReset+" │", hand.health)
-		//fmt.Print("│", clrHealth, hand.health, clrReset, " │")
-		fmt.Printf(clrPower+"%3d"+clrReset+" │\n", hand.power)
+		fmt.Printf("│"+clrHealth+"%3d"+clrReset+" │", hand.Health)
+		//fmt.Print("│", clrHealth, hand.Health, clrReset, " │")
+		fmt.Printf(clrPower+"%3d"+clrReset+" │\n", hand.Power)
 		fmt.Println("└────┴" + strings.Repeat("─", 15) + "┴────┘") */
 }
 
-func drawTable(firstHand Hand, secondHand Hand) {
+//selectionString will return the attack of the selected card, power is shown as ?? if it's hidden
+func selectionString(hand game.Hand) string {
+	card := hand.Cards[hand.SelectedCard]
+	if hand.SelectedPower == game.HiddenPower {
+		return fmt.Sprint(card.Value, "+", card.Value, "*??")
+	}
+	return fmt.Sprint(card.Value, "+", card.Value, "*", hand.SelectedPower)
+}
+
+//tableState will return the state of the player with the opponent hand shown as on the table, so the human gets only the visible information
+func tableState(state State) State {
+	var hands [2]game.Hand
+	hands[state.Seat] = state.Hand
+	hands[game.Opponent(state.Seat)] = state.Opponent
+	hands[game.Comp], hands[game.User] = visibleHands(hands[game.Comp], hands[game.User])
+	state.Opponent = hands[game.Opponent(state.Seat)]
+	return state
+}
+
+//visibleHand will return the hand as the opponent sees it until the battle. Selection is hidden for the simultaneous moves,
+//power of the selected card is hidden in the hidden power mode. Players, tables and network clients get the opponent hands from here
+func visibleHand(hand game.Hand) game.Hand {
+	switch {
+	case gameRules.Simultaneous:
+		return hideSelection(hand)
+	case gameRules.HiddenPower:
+		return hand.HidePower()
+	}
+	return hand
+}
+
+//visibleHands will return the comp and user hands on the table until the battle, both players see the table, so both hands are shown as the opponent sees them
+func visibleHands(compHand game.Hand, userHand game.Hand) (game.Hand, game.Hand) {
+	return visibleHand(compHand), visibleHand(userHand)
+}
+
+func drawTable(firstHand game.Hand, secondHand game.Hand) {
 	//clearScreen()
-	fmt.Print("\033[H\033[2J")
+	fmt.Fprint(screen, clearScreen)
 
-	drawHand(firstHand)
+	cardsNum := tableCards(firstHand, secondHand)
+	width := frameWidth(cardsNum)
+	drawHand(firstHand, cardsNum)
 
 	//fmt.Println("╔" + strings.Repeat("═", 25) + "╗")
 	//fmt.Println("║" + strings.Repeat(" ", 25) + "║")
-	fmt.Println("╔════════════════════════════╗")
-	fmt.Println("║                            ║")
+	fmt.Fprintln(screen, "╔"+strings.Repeat("═", width)+"╗")
+	fmt.Fprintln(screen, "║"+strings.Repeat(" ", width)+"║")
 
-	if firstHand.selectedCard != -1 {
-		//tmpString := fmt.Sprint(firstHand.cards[firstHand.selectedCard].value, "+", firstHand.cards[firstHand.selectedCard].value, "*", firstHand.selectedPower)
-		tmpString := fmt.Sprint(firstHand.cards[firstHand.selectedCard].value, "+", firstHand.cards[firstHand.selectedCard].value, "*??")
-		fmt.Printf("║ "+clrPlayableCardPower+"%v"+clrReset, tmpString)
+	if firstHand.SelectedCard != -1 {
+		//tmpString := fmt.Sprint(firstHand.Cards[firstHand.SelectedCard].Value, "+", firstHand.Cards[firstHand.SelectedCard].Value, "*", firstHand.SelectedPower)
+		tmpString := selectionString(firstHand)
+		fmt.Fprintf(screen, "║ "+clrPlayableCardPower+"%v"+clrReset, tmpString)
 
-		fmt.Println(strings.Repeat(" ", 27-len(tmpString)) + "║")
-		//fmt.Printf("║\033[32m%4d\033[0m+\033[31m%-20v\033[0m║\n", firstHand.cards[firstHand.selectedCard].value, "?")
+		fmt.Fprintln(screen, spaces(width-1-len(tmpString))+"║")
+		//fmt.Printf("║\033[32m%4d\033[0m+\033[31m%-20v\033[0m║\n", firstHand.Cards[firstHand.SelectedCard].Value, "?")
 	} else {
-		fmt.Println("║                            ║")
+		fmt.Fprintln(screen, "║"+strings.Repeat(" ", width)+"║")
 	}
 
-	fmt.Println("║                            ║")
+	fmt.Fprintln(screen, "║"+strings.Repeat(" ", width)+"║")
 
-	if secondHand.selectedCard != -1 {
-		tmpString := fmt.Sprint(secondHand.cards[secondHand.selectedCard].value, "+", secondHand.cards[secondHand.selectedCard].value, "*", secondHand.selectedPower)
-		fmt.Printf("║"+clrPlayableCardPower+"%27v"+clrReset, tmpString)
-		fmt.Println(" ║")
+	if secondHand.SelectedCard != -1 {
+		tmpString := selectionString(secondHand)
+		fmt.Fprintf(screen, "║"+clrPlayableCardPower+"%*v"+clrReset, width-1, tmpString)
+		fmt.Fprintln(screen, " ║")
 
-		//fmt.Printf("║"+clrCardPower+"%20d"+clrReset+"+"+clrCardPower+"%-4d"+clrReset+"║\n", secondHand.cards[secondHand.selectedCard].value, secondHand.selectedPower)
-		//fmt.Printf("║\033[32m%20d\033[0m+\033[31m%-4d\033[0m║\n", secondHand.cards[secondHand.selectedCard].value, secondHand.selectedPower)
+		//fmt.Printf("║"+clrCardPower+"%20d"+clrReset+"+"+clrCardPower+"%-4d"+clrReset+"║\n", secondHand.Cards[secondHand.SelectedCard].Value, secondHand.SelectedPower)
+		//fmt.Printf("║\033[32m%20d\033[0m+\033[31m%-4d\033[0m║\n", secondHand.Cards[secondHand.SelectedCard].Value, secondHand.SelectedPower)
 
-		//selectedCards += "\033[32m" + strconv.Itoa(secondHand.cards[secondHand.selectedCard].value) + "\033[0m+"
-		//selectedCards += "\033[31m" + strconv.Itoa(secondHand.selectedPower) + "\033[0m    ++"
+		//selectedCards += "\033[32m" + strconv.Itoa(secondHand.Cards[secondHand.SelectedCard].Value) + "\033[0m+"
+		//selectedCards += "\033[31m" + strconv.Itoa(secondHand.SelectedPower) + "\033[0m    ++"
 	} else {
-		fmt.Println("║                            ║")
+		fmt.Fprintln(screen, "║"+strings.Repeat(" ", width)+"║")
 	}
 
-	fmt.Println("║                            ║")
-	fmt.Println("╚════════════════════════════╝")
+	fmt.Fprintln(screen, "║"+strings.Repeat(" ", width)+"║")
+	fmt.Fprintln(screen, "╚"+strings.Repeat("═", width)+"╝")
 
-	drawHand(secondHand)
+	drawHand(secondHand, cardsNum)
 
 }
 
-func drawBattle(firstHand Hand, secondHand Hand) {
+func drawBattle(firstHand game.Hand, secondHand game.Hand) {
 
-	compTotalPower := firstHand.cards[firstHand.selectedCard].value + firstHand.selectedPower*firstHand.cards[firstHand.selectedCard].value
-	compTotalPowerString := fmt.Sprint(firstHand.cards[firstHand.selectedCard].value, "+", firstHand.cards[firstHand.selectedCard].value, "*", firstHand.selectedPower, "=", compTotalPower)
-	userTotalPower := secondHand.cards[secondHand.selectedCard].value + secondHand.selectedPower*secondHand.cards[secondHand.selectedCard].value
-	userTotalPowerString := fmt.Sprint(secondHand.cards[secondHand.selectedCard].value, "+", secondHand.cards[secondHand.selectedCard].value, "*", secondHand.selectedPower, "=", userTotalPower)
+	compTotalPower := firstHand.Cards[firstHand.SelectedCard].Value + firstHand.SelectedPower*firstHand.Cards[firstHand.SelectedCard].Value
+	compTotalPowerString := fmt.Sprint(firstHand.Cards[firstHand.SelectedCard].Value, "+", firstHand.Cards[firstHand.SelectedCard].Value, "*", firstHand.SelectedPower, "=", compTotalPower)
+	userTotalPower := secondHand.Cards[secondHand.SelectedCard].Value + secondHand.SelectedPower*secondHand.Cards[secondHand.SelectedCard].Value
+	userTotalPowerString := fmt.Sprint(secondHand.Cards[secondHand.SelectedCard].Value, "+", secondHand.Cards[secondHand.SelectedCard].Value, "*", secondHand.SelectedPower, "=", userTotalPower)
 	totalLen := len(compTotalPowerString) + len(userTotalPowerString) + 4
-	fmt.Print("\033[H\033[2J")
-
-	drawHand(firstHand)
-
-	fmt.Println("╔════════════════════════════╗")
-	fmt.Println("║                            ║")
-	fmt.Print("║" + strings.Repeat(" ", (28-totalLen)/2))
-	fmt.Print(clrPlayableCardPower + compTotalPowerString + clrReset + " vs " + clrPlayableCardPower + userTotalPowerString + clrReset)
-	fmt.Println(strings.Repeat(" ", (29-totalLen)/2) + "║")
+	fmt.Fprint(screen, clearScreen)
+
+	cardsNum := tableCards(firstHand, secondHand)
+	width := frameWidth(cardsNum)
+	//Messages are 10 characters wide
+	messagePadding := strings.Repeat(" ", (width-10)/2)
+	drawHand(firstHand, cardsNum)
+
+	fmt.Fprintln(screen, "╔"+strings.Repeat("═", width)+"╗")
+	fmt.Fprintln(screen, "║"+strings.Repeat(" ", width)+"║")
+	fmt.Fprint(screen, "║"+spaces((width-totalLen)/2))
+	fmt.Fprint(screen, clrPlayableCardPower+compTotalPowerString+clrReset+" vs "+clrPlayableCardPower+userTotalPowerString+clrReset)
+	fmt.Fprintln(screen, spaces((width+1-totalLen)/2)+"║")
 	if userTotalPower > compTotalPower {
-		fmt.Println("║" + strings.Repeat(" ", 9) + clrGoodMessage + "USER  WINS" + clrReset + strings.Repeat(" ", 9) + "║")
-		fmt.Printf("║"+strings.Repeat(" ", 9)+clrGoodMessage+"DAMAGE:%3d"+clrReset+strings.Repeat(" ", 9)+"║\n", secondHand.cards[secondHand.selectedCard].damage)
+		fmt.Fprintln(screen, "║"+messagePadding+clrGoodMessage+"USER  WINS"+clrReset+messagePadding+"║")
+		fmt.Fprintf(screen, "║"+messagePadding+clrGoodMessage+"DAMAGE:%3d"+clrReset+messagePadding+"║\n", secondHand.Cards[secondHand.SelectedCard].Damage)
 	} else if userTotalPower < compTotalPower {
-		fmt.Println("║" + strings.Repeat(" ", 9) + clrBadMessage + "COMP  WINS" + clrReset + strings.Repeat(" ", 9) + "║")
-		fmt.Printf("║"+strings.Repeat(" ", 9)+clrBadMessage+"DAMAGE:%3d"+clrReset+strings.Repeat(" ", 9)+"║\n", firstHand.cards[firstHand.selectedCard].damage)
+		fmt.Fprintln(screen, "║"+messagePadding+clrBadMessage+"COMP  WINS"+clrReset+messagePadding+"║")
+		fmt.Fprintf(screen, "║"+messagePadding+clrBadMessage+"DAMAGE:%3d"+clrReset+messagePadding+"║\n", firstHand.Cards[firstHand.SelectedCard].Damage)
 	} else {
-		fmt.Println("║                            ║")
-		fmt.Println("║" + strings.Repeat(" ", 12) + clrGoodMessage + "DRAW" + clrReset + strings.Repeat(" ", 12) + "║")
+		fmt.Fprintln(screen, "║"+strings.Repeat(" ", width)+"║")
+		fmt.Fprintln(screen, "║"+strings.Repeat(" ", (width-4)/2)+clrGoodMessage+"DRAW"+clrReset+strings.Repeat(" ", (width-4)/2)+"║")
 	}
 
-	fmt.Println("║                            ║")
-	fmt.Println("╚════════════════════════════╝")
+	fmt.Fprintln(screen, "║"+strings.Repeat(" ", width)+"║")
+	fmt.Fprintln(screen, "╚"+strings.Repeat("═", width)+"╝")
 
-	drawHand(secondHand)
+	drawHand(secondHand, cardsNum)
+}
 
+//readInput will read the line entered by the user, input is not shown in the terminal in the simultaneous mode, so the hot seat opponent can't see it
+func readInput(scanner *bufio.Scanner) string {
+	fd := int(os.Stdin.Fd())
+	if gameRules.Simultaneous && term.IsTerminal(fd) {
+		input, _ := term.ReadPassword(fd)
+		fmt.Println()
+		return string(input)
+	}
+	scanner.Scan()
+	return scanner.Text()
 }
 
-func processUserTurn(userHand Hand) Hand {
+func processUserTurn(state State) (int, int) {
 	var cardNumber, cardPower int
 	var err error
 
+	userHand := state.Hand
 	scanner := bufio.NewScanner(os.Stdin)
 
-	userHand.selectedCard = -1
 	for {
-		fmt.Print("Enter card number: ")
-		scanner.Scan()
-		cardNumber, err = strconv.Atoi(scanner.Text())
+		fmt.Print("Enter card number (? for hint): ")
+		input := readInput(scanner)
+		if input == "?" {
+			fmt.Print(moveHint(state))
+			continue
+		}
+		cardNumber, err = strconv.Atoi(input)
 		if err != nil {
 			fmt.Println("Unrecognized character")
 			continue
 		} else {
-			if cardNumber > HandSize || cardNumber < 1 {
-				fmt.Println("Incorrect card number. Card number range is 1 ..", HandSize)
+			if cardNumber > len(userHand.Cards) || cardNumber < 1 {
+				fmt.Println("Incorrect card number. Card number range is 1 ..", len(userHand.Cards))
 				continue
 			}
-			if !userHand.cards[cardNumber-1].playable {
+			if !userHand.Cards[cardNumber-1].Playable {
 				fmt.Println("Card already played. Please choose another")
 				continue
 			}
 		}
-		userHand.selectedCard = cardNumber - 1
 		break
 	}
-	if userHand.power > 0 {
+	if userHand.Power > 0 {
 		for {
 			fmt.Print("Enter power: ")
-			scanner.Scan()
-			cardPower, err = strconv.Atoi(scanner.Text())
+			cardPower, err = strconv.Atoi(readInput(scanner))
 			if err != nil {
 				fmt.Println("Unrecognized character")
 				continue
 			} else {
-				if cardPower > userHand.power || cardPower < 0 {
-					fmt.Println("Incorrect power value. Power value range is 0 ..", userHand.power)
+				if cardPower > userHand.Power || cardPower < 0 {
+					fmt.Println("Incorrect power value. Power value range is 0 ..", userHand.Power)
 					continue
 				}
 			}
@@ -308,131 +378,218 @@ func processUserTurn(userHand Hand) Hand {
 	} else {
 		cardPower = 0
 	}
-	userHand.selectedPower = cardPower
-	return userHand
+	return cardNumber - 1, cardPower
 }
 
-func processCompTurn(compHand Hand, userHand Hand) Hand {
-	var cardNumber, cardPower int
-	var playableCards int
-	for i, v := range compHand.cards {
-		if v.playable {
-			playableCards++
-			cardNumber = i
-			cardPower = compHand.power
+//playerState will return the game state visible to the player
+func playerState(g *game.Game, player int) State {
+	state := State{
+		Seat:     player,
+		Hand:     g.Hand(player),
+		Opponent: visibleHand(g.Hand(game.Opponent(player))),
+	}
+	return state
+}
+
+//processTurn will ask the player who should move now for the card and power
+func processTurn(g *game.Game, players [2]Player) game.Move {
+	move := game.Move{Player: g.ToMove()}
+	move.Card, move.Power = players[move.Player].ChooseMove(playerState(g, move.Player))
+	return move
+}
+
+//newSeededTable will deal the hands and select the first mover, random generator is returned for the further decisions derived from the seed
+func newSeededTable(seed int64, rules dealRules) (*game.Game, *rand.Rand) {
+	rnd := rand.New(rand.NewSource(seed))
+
+	hands := dealHands(rules, rnd)
+	Logger.Debug(hands[game.Comp])
+	Logger.Debug(hands[game.User])
+
+	//Select first player randomly
+	firstMover := game.Comp
+	if rnd.Intn(2) == 0 {
+		firstMover = game.User
+	}
+	return game.New(hands[game.Comp], hands[game.User], firstMover), rnd
+}
+
+//newSeededGame will deal the hands, select the first mover and create players, all random decisions are derived from the seed.
+//Names of the players select the opponent models, player types are used for the players without the name
+func newSeededGame(seed int64, playerTypes [2]string, names [2]string, rules dealRules) (*game.Game, [2]Player, error) {
+	var players [2]Player
+	g, rnd := newSeededTable(seed, rules)
+
+	//Every player has own random generator, so player decisions don't depend on the opponent type
+	for i, v := range playerTypes {
+		player, err := newPlayer(v, rand.New(rand.NewSource(rnd.Int63())))
+		if err != nil {
+			return nil, players, err
+		}
+		//GA player weights the opponent plans by the model of the opponent
+		if gaPlayer, ok := player.(GAPlayer); ok {
+			gaPlayer.model = opponentModel(playerIdentity(names[game.Opponent(i)], playerTypes[game.Opponent(i)]))
+			player = gaPlayer
 		}
+		players[i] = player
 	}
-	if playableCards > 1 {
-		cardNumber, cardPower = GetNextMove(compHand, userHand)
-	}
-	//	compHand.selectedCard = -1
-	/* 	for {
-	   		cardNumber = rand.Intn(HandSize)
-	   		if compHand.cards[cardNumber].playable {
-	   			break
-	   		}
-	   	}
-	   	cardPower = rand.Intn(compHand.power + 1)
-	*/
-	compHand.selectedCard = cardNumber
-	compHand.selectedPower = cardPower
-	return compHand
+	return g, players, nil
 }
 
-func main() {
+//playGame will play a single game in the terminal
+func playGame(args []string) {
+	flags := flag.NewFlagSet("kaart", flag.ExitOnError)
+	compPlayer := flags.String("comp", playerGA, playerUsage("player in the comp seat"))
+	userPlayer := flags.String("user", playerHuman, playerUsage("player in the user seat"))
+	compName := flags.String("comp-name", "", "name of the player in the comp seat, models of the players are keyed by the name, player type is used if it is empty")
+	userName := flags.String("user-name", "", "name of the player in the user seat, models of the players are keyed by the name, player type is used if it is empty")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed for the deal and bot decisions, the same seed replays the same game")
+	recordPath := flags.String("record", "games.jsonl", "JSON Lines file to append the game record to, empty to disable")
+	useTUI := flags.Bool("tui", true, "full screen terminal UI with the keyboard card selection, prompts are used if disabled or the input is not a terminal")
+	flags.IntVar(&explainPlansNumber, "explain", 0, "show N best plans of the ga players with the estimated win rates after every battle, 0 to disable")
+	modelPath := flags.String("model", "", "JSON file with the power models of the players, ga players weight the opponent plans by the model of the opponent name or type, models are updated after the game")
+	rules := addDealFlags(flags)
+	addBotFlags(flags)
+	gameRulesFlags := addRulesFlags(flags)
+	flags.Parse(args)
+	if err := gameRulesFlags.load(); err != nil {
+		Logger.Fatal(err)
+	}
+	if err := rules.validate(); err != nil {
+		Logger.Fatal(err)
+	}
+	if explainPlansNumber < 0 {
+		Logger.Fatal("number of explained plans should not be negative")
+	}
+	models, err := loadPowerModels(*modelPath)
+	if err != nil {
+		Logger.Fatal(err)
+	}
+	opponentModels = models
 
-	rand.Seed(time.Now().UnixNano())
-
-	userHand := initHand()
-	compHand := initHand()
-	Logger.Debug(compHand)
-	Logger.Debug(userHand)
-
-	Logger.Debug(compHand)
-	Logger.Debug(userHand)
-	//Logger.Fatal("!TEMP END!")
-	//Bool value to select current player
-	isUserTurn := rand.Intn(2) == 0
-	//isTableDirty := true
-	var totalUserPower, totalCompPower int
-	//var cardNumber, cardPower int
-	//var err error
-
-	for i := 0; i < HandSize; i++ {
-		userHand.selectedCard = -1
-		compHand.selectedCard = -1
-		compHand.active = !isUserTurn
-		userHand.active = isUserTurn
-		drawTable(compHand, userHand)
-		if isUserTurn {
-			fmt.Println("USER TURN")
-			userHand = processUserTurn(userHand)
-			drawTable(compHand, userHand)
-			compHand = processCompTurn(compHand, userHand)
-		} else {
-			fmt.Println("COMP TURN")
-			compHand = processCompTurn(compHand, userHand)
-			drawTable(compHand, userHand)
-			userHand = processUserTurn(userHand)
+	names := [2]string{*compName, *userName}
+	g, players, err := newSeededGame(*seed, [2]string{*compPlayer, *userPlayer}, names, *rules)
+	if err != nil {
+		Logger.Fatal(err)
+	}
+	record := newGameRecord(*seed, [2]string{*compPlayer, *userPlayer}, names, g)
+	if *useTUI {
+		if terminal, err = newTUI(); err != nil {
+			Logger.Debug("terminal UI is disabled: ", err)
+		}
+	}
+
+	//Players pass the keyboard in the hot seat game, so the next player doesn't see the previous move
+	_, compHuman := players[game.Comp].(HumanPlayer)
+	_, userHuman := players[game.User].(HumanPlayer)
+	handOff := func(player int) {
+		if compHuman && userHuman {
+			fmt.Fprint(screen, clearScreen)
+			pause(fmt.Sprintf("%v player, press 'Enter' when the other player doesn't look...", seatNames[player]))
 		}
-		drawTable(compHand, userHand)
-		fmt.Print("Press 'Enter' for the turn results...")
-		bufio.NewReader(os.Stdin).ReadBytes('\n')
-		drawBattle(compHand, userHand)
-		userHand.power -= userHand.selectedPower
-		compHand.power -= compHand.selectedPower
-		userHand.cards[userHand.selectedCard].playable = false
-		compHand.cards[compHand.selectedCard].playable = false
-		totalUserPower = userHand.cards[userHand.selectedCard].value * (userHand.selectedPower + 1)
-		totalCompPower = compHand.cards[compHand.selectedCard].value * (compHand.selectedPower + 1)
-		if totalUserPower > totalCompPower {
-			compHand.health -= userHand.cards[userHand.selectedCard].damage
+	}
+
+	for !g.IsOver() {
+		if gameRules.Simultaneous {
+			if err := playSimultaneousTurn(g, players, handOff); err != nil {
+				terminal.close()
+				Logger.Fatal(err)
+			}
+			battle := g.LastBattle()
+			//Both moves are revealed only in the battle
+			drawTable(visibleHands(battle.Hands[game.Comp], battle.Hands[game.User]))
+			fmt.Fprintln(screen, "BOTH MOVES COMMITTED")
+			pause("Press 'Enter' for the turn results...")
+			drawBattle(battle.Hands[game.Comp], battle.Hands[game.User])
+			fmt.Fprint(screen, battleNotes(players))
+			if !g.IsOver() {
+				pause("Press 'Enter' for the next turn...")
+			}
+			continue
+		}
+		drawTable(visibleHands(g.Hand(game.Comp), g.Hand(game.User)))
+		if g.ToMove() == game.User {
+			fmt.Fprintln(screen, "USER TURN")
 		} else {
-			userHand.health -= compHand.cards[compHand.selectedCard].damage
+			fmt.Fprintln(screen, "COMP TURN")
+		}
+		//First player selects the card
+		if err := g.Apply(processTurn(g, players)); err != nil {
+			terminal.close()
+			Logger.Fatal(err)
+		}
+		drawTable(visibleHands(g.Hand(game.Comp), g.Hand(game.User)))
+		//Second player selects the card and the battle is resolved
+		if err := g.Apply(processTurn(g, players)); err != nil {
+			terminal.close()
+			Logger.Fatal(err)
 		}
+		battle := g.LastBattle()
+		drawTable(visibleHands(battle.Hands[game.Comp], battle.Hands[game.User]))
+		pause("Press 'Enter' for the turn results...")
+		drawBattle(battle.Hands[game.Comp], battle.Hands[game.User])
+		fmt.Fprint(screen, battleNotes(players))
+
+		if !g.IsOver() {
+			pause("Press 'Enter' for the next turn...")
+		}
+	}
 
-		if userHand.health < 1 || compHand.health < 1 {
-			break
+	record.finish(g)
+	if *recordPath != "" {
+		if err := appendGameRecord(*recordPath, record); err != nil {
+			Logger.Error(err)
 		}
-		if i < HandSize-1 {
-			fmt.Print("Press 'Enter' for the next turn...")
-			bufio.NewReader(os.Stdin).ReadBytes('\n')
+	}
+	//Models learn the moves of this game for the next sessions
+	if *modelPath != "" {
+		opponentModels.learn(record)
+		if err := opponentModels.save(*modelPath); err != nil {
+			Logger.Error(err)
 		}
+	}
+
+	pause("Press 'Enter' for the game results...")
+	drawResult(g)
+	terminal.close()
+	fmt.Println("\nSeed:", *seed)
+}
 
-		isUserTurn = !isUserTurn
+//drawResult will draw the final table and the winner of the game
+func drawResult(g *game.Game) {
+	drawTable(visibleHands(g.Hand(game.Comp), g.Hand(game.User)))
+	switch g.Winner() {
+	case game.Draw:
+		fmt.Fprint(screen, "DRAW")
+	case game.Comp:
+		fmt.Fprint(screen, "COMP WINS")
+	default:
+		fmt.Fprint(screen, "USER WINS")
 	}
+}
 
-	fmt.Print("Press 'Enter' for the game results...")
-	bufio.NewReader(os.Stdin).ReadBytes('\n')
-	userHand.selectedCard = -1
-	compHand.selectedCard = -1
-	drawTable(compHand, userHand)
-	if userHand.health == compHand.health {
-		fmt.Print("DRAW")
-	} else if userHand.health < compHand.health {
-		fmt.Print("COMP WINS")
-	} else {
-		fmt.Print("USER WINS")
-	}
-	//Loop User Hand
-	//askForCard()
-	//askForPower()
-
-	//Loop Comp Hand
-	//calcBestPlay()
-
-	//Loop until run out of cards or out of HP
-
-	/* 	total := 0
-	   	for a := 0; a <= 12; a++ {
-	   		for b := 0; b <= 12-a; b++ {
-	   			for c := 0; c <= 12-a-b; c++ {
-	   				d := 12 - a - b - c
-	   				fmt.Println(a, b, c, d)
-	   				total++
-	   			}
-	   		}
-	   	}
-	   	fmt.Println(total)
-	*/
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "tournament":
+			runTournament(os.Args[2:])
+			return
+		case "replay":
+			runReplay(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "connect":
+			runConnect(os.Args[2:])
+			return
+		case "api":
+			runAPI(os.Args[2:])
+			return
+		case "learn":
+			runLearn(os.Args[2:])
+			return
+		}
+	}
+	playGame(os.Args[1:])
 }
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+func TestInitHandDefaultRules(t *testing.T) {
+	//Hands dealt by the original 4 cards dealer, they should stay the same for the recorded seeds
+	tests := []struct {
+		seed   int64
+		values []int
+		total  int
+	}{
+		{1, []int{8, 3, 8, 7}, 26},
+		{42, []int{7, 6, 7, 6}, 26},
+		{2021, []int{7, 3, 5, 8}, 23},
+	}
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	gameRules = game.DefaultRules
+	for _, tt := range tests {
+		hand := initHand(rand.New(rand.NewSource(tt.seed)))
+		total := 0
+		for i, v := range hand.Cards {
+			total += v.Value
+			if v.Value != tt.values[i] {
+				t.Errorf("seed %v: card %v value = %v, want %v", tt.seed, i, v.Value, tt.values[i])
+			}
+		}
+		if total != tt.total {
+			t.Errorf("seed %v: total value = %v, want %v", tt.seed, total, tt.total)
+		}
+	}
+}
+
+func TestInitHandSizes(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	for handSize := 2; handSize <= game.MaxHandSize; handSize++ {
+		gameRules = game.DefaultRules
+		gameRules.HandSize = handSize
+		rnd := rand.New(rand.NewSource(int64(handSize)))
+		for i := 0; i < 100; i++ {
+			hand := initHand(rnd)
+			if len(hand.Cards) != handSize || hand.PlayableCards() != handSize {
+				t.Fatalf("hand size %v: dealt %v cards, %v playable", handSize, len(hand.Cards), hand.PlayableCards())
+			}
+			total := 0
+			for _, v := range hand.Cards {
+				total += v.Value
+				if v.Value < 1 || v.Value > gameRules.MaxRank || v.Damage < gameRules.MinDamage {
+					t.Fatalf("hand size %v: card %+v is out of the rules", handSize, v)
+				}
+			}
+			if maxTotal := (handSize-1)*gameRules.MaxRank + gameRules.MinRank; total > maxTotal {
+				t.Fatalf("hand size %v: total value %v is above %v", handSize, total, maxTotal)
+			}
+		}
+	}
+}
+
+func TestNewSeededGameModel(t *testing.T) {
+	savedRules, savedModels := gameRules, opponentModels
+	defer func() { gameRules, opponentModels = savedRules, savedModels }()
+	gameRules = game.DefaultRules
+	nameModel, typeModel := &powerModel{Moves: 1}, &powerModel{Moves: 2}
+	opponentModels = powerModels{"alice": nameModel, playerHuman: typeModel}
+	tests := []struct {
+		name  string
+		names [2]string
+		want  *powerModel
+	}{
+		{"opponent name selects the model", [2]string{"", "alice"}, nameModel},
+		{"opponent type is used without the name", [2]string{"bob", ""}, typeModel},
+		{"unknown opponent name has no model", [2]string{"", "carol"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, players, err := newSeededGame(1, [2]string{playerGA, playerHuman}, tt.names, dealRules{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := players[game.Comp].(GAPlayer).model; got != tt.want {
+				t.Errorf("model = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeededGameRepeats(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	tests := []struct {
+		name         string
+		players      [2]string
+		rules        dealRules
+		simultaneous bool
+	}{
+		{"ga against random", [2]string{playerGA, playerRandom}, dealRules{dealer: dealerRandom}, false},
+		{"mcts against greedy from the deck", [2]string{playerMCTS, playerGreedy}, dealRules{dealer: dealerDeck, tiers: tiersFlag{1, 1, 1, 1}}, false},
+		{"simultaneous ga against mcts", [2]string{"ga:maximin", playerMCTS}, dealRules{dealer: dealerRandom}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameRules = game.DefaultRules
+			gameRules.Simultaneous = tt.simultaneous
+			//playSeeded will play the game to the end and return its battles
+			playSeeded := func(seed int64) []game.Battle {
+				g, players, err := newSeededGame(seed, tt.players, [2]string{}, tt.rules)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := playHeadless(g, players); err != nil {
+					t.Fatal(err)
+				}
+				return g.Battles()
+			}
+			for seed := int64(1); seed <= 2; seed++ {
+				first, second := playSeeded(seed), playSeeded(seed)
+				if len(first) == 0 {
+					t.Fatalf("seed %v: game has no battles", seed)
+				}
+				if !reflect.DeepEqual(first, second) {
+					t.Errorf("seed %v: battles of the same seed differ\n%+v\n%+v", seed, first, second)
+				}
+			}
+		})
+	}
+}
+
+func TestVisibleHands(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	tests := []struct {
+		name         string
+		hiddenPower  bool
+		simultaneous bool
+		wantCard     int
+		wantPower    int
+	}{
+		{"default rules", false, false, 1, 2},
+		{"hidden power", true, false, 1, game.HiddenPower},
+		{"simultaneous moves", false, true, -1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameRules = game.DefaultRules
+			gameRules.HiddenPower, gameRules.Simultaneous = tt.hiddenPower, tt.simultaneous
+			for _, mover := range []int{game.Comp, game.User} {
+				hand := game.Hand{Health: 10, Power: 6, SelectedCard: -1, Cards: []game.Card{{Value: 2, Damage: 1, Playable: true}, {Value: 3, Damage: 2, Playable: true}}}
+				g := game.New(hand, hand.Copy(), mover)
+				if err := g.Apply(game.Move{Player: mover, Card: 1, Power: 2}); err != nil {
+					t.Fatal(err)
+				}
+				//Opponent of the mover gets the same hand in the player state, on the table and over the network
+				other := game.Opponent(mover)
+				var table [2]game.Hand
+				table[game.Comp], table[game.User] = visibleHands(g.Hand(game.Comp), g.Hand(game.User))
+				views := map[string]game.Hand{
+					"player state": playerState(g, other).Opponent,
+					"table":        table[mover],
+					"table state":  tableState(State{Seat: other, Hand: g.Hand(other), Opponent: g.Hand(mover)}).Opponent,
+					"network":      clientHands(g, other)[mover],
+				}
+				for name, v := range views {
+					if v.SelectedCard != tt.wantCard || v.SelectedPower != tt.wantPower {
+						t.Errorf("mover %v: %v has selection %v with power %v, want %v with power %v", mover, name, v.SelectedCard, v.SelectedPower, tt.wantCard, tt.wantPower)
+					}
+				}
+				//Mover sees own selection
+				if own := playerState(g, mover).Hand; own.SelectedCard != 1 || own.SelectedPower != 2 {
+					t.Errorf("mover %v: own selection %v with power %v", mover, own.SelectedCard, own.SelectedPower)
+				}
+			}
+		})
+	}
+}
+
+func TestSpaces(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{3, "   "},
+		{0, ""},
+		//Text wider than the frame
+		{-5, ""},
+	}
+	for _, tt := range tests {
+		if got := spaces(tt.n); got != tt.want {
+			t.Errorf("spaces(%v) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDrawHandWidth(t *testing.T) {
+	savedScreen := screen
+	defer func() { screen = savedScreen }()
+	colors := regexp.MustCompile("\033\\[[0-9;]*m")
+	largestHand := game.Hand{Health: game.MaxRulesValue, Power: game.MaxRulesValue, SelectedCard: 1}
+	for i := 0; i < game.MaxHandSize; i++ {
+		largestHand.Cards = append(largestHand.Cards, game.Card{Value: game.MaxRulesValue, Damage: game.MaxRulesValue, Playable: i%2 == 0})
+	}
+	deadHand := largestHand.Copy()
+	deadHand.Health = 1 - game.MaxRulesValue
+	deadHand.Power = 0
+	tests := []struct {
+		name     string
+		hand     game.Hand
+		cardsNum int
+	}{
+		{"largest values", largestHand, game.MaxHandSize},
+		{"dead player", deadHand, game.MaxHandSize},
+		{"smaller hand", game.Hand{Health: 5, Power: 7, SelectedCard: -1, Cards: largestHand.Cards[:2]}, game.MaxHandSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			screen = &output
+			drawHand(tt.hand, tt.cardsNum)
+			for _, v := range strings.Split(strings.TrimSuffix(colors.ReplaceAllString(output.String(), ""), "\n"), "\n") {
+				if width := utf8.RuneCountInString(v); width != frameWidth(tt.cardsNum)+2 {
+					t.Errorf("line %q has width %v, want %v", v, width, frameWidth(tt.cardsNum)+2)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+	"time"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//MCTS parameters
+var (
+	mctsIterations  int           = 20000 //number of search iterations for every move
+	mctsTimeLimit   time.Duration = 0     //search time for every move, overrides mctsIterations if it is not 0
+	mctsExploration float32       = 1.4   //exploration constant of the UCB1 move selection
+)
+
+//mctsChoice is the statistics of the player moves in one information set, values are the payoffs of this player
+type mctsChoice struct {
+	visits     int
+	moveVisits []int
+	moveValues []float64
+}
+
+//mctsNode is a single turn of the game. Players keep separate statistics, because the second mover sees only the part of the first mover move allowed by the rules,
+//so the second mover statistics are shared by all first mover moves with the same visible part
+type mctsNode struct {
+	state      solverState
+	firstMover int                  //game.Comp or game.User in terms of the solver, comp is the bot
+	moves      [2][]solverMove      //moves of the comp and the user
+	first      *mctsChoice          //statistics of the first mover
+	second     map[int]*mctsChoice  //statistics of the second mover by the observed part of the first mover move
+	children   map[[2]int]*mctsNode //next turns by the indexes of the comp and the user moves
+}
+
+//mctsSearch is the tree of the game turns from the current state of the bot
+type mctsSearch struct {
+	solver *solver
+	root   *mctsNode
+	nodes  int
+	rand   *rand.Rand
+}
+
+func newMCTSChoice(movesNum int) *mctsChoice {
+	return &mctsChoice{moveVisits: make([]int, movesNum), moveValues: make([]float64, movesNum)}
+}
+
+func newMCTSNode(state solverState, firstMover int, compMoves []solverMove, userMoves []solverMove) *mctsNode {
+	node := &mctsNode{
+		state:      state,
+		firstMover: firstMover,
+		second:     make(map[int]*mctsChoice),
+		children:   make(map[[2]int]*mctsNode),
+	}
+	node.moves[game.Comp] = compMoves
+	node.moves[game.User] = userMoves
+	node.first = newMCTSChoice(len(node.moves[firstMover]))
+	return node
+}
+
+//selectMove will return the index of the random not visited move, or the move with the best UCB1 score if all moves are visited
+func (c *mctsChoice) selectMove(rnd *rand.Rand) int {
+	if c.visits < len(c.moveVisits) {
+		selected, unvisited := 0, 0
+		for i, v := range c.moveVisits {
+			if v == 0 {
+				unvisited++
+				if rnd.Intn(unvisited) == 0 {
+					selected = i
+				}
+			}
+		}
+		return selected
+	}
+	logVisits := math.Log(float64(c.visits))
+	selected, bestScore := 0, math.Inf(-1)
+	for i, v := range c.moveVisits {
+		score := c.moveValues[i]/float64(v) + float64(mctsExploration)*math.Sqrt(logVisits/float64(v))
+		if score > bestScore {
+			selected, bestScore = i, score
+		}
+	}
+	return selected
+}
+
+//update will add the payoff of the player to the move statistics
+func (c *mctsChoice) update(move int, payoff float32) {
+	c.visits++
+	c.moveVisits[move]++
+	c.moveValues[move] += float64(payoff)
+}
+
+//mctsObservation will return the part of the first mover move visible to the second mover, moves with the same observation can't be told apart
+func mctsObservation(move solverMove, moveIndex int) int {
+	switch {
+	case gameRules.Simultaneous:
+		return 0
+	case gameRules.HiddenPower:
+		return move.card
+	}
+	return moveIndex
+}
+
+//secondChoice will return the statistics of the second mover after the first mover move
+func (node *mctsNode) secondChoice(firstMove int) *mctsChoice {
+	observation := mctsObservation(node.moves[node.firstMover][firstMove], firstMove)
+	choice, ok := node.second[observation]
+	if !ok {
+		choice = newMCTSChoice(len(node.moves[game.Opponent(node.firstMover)]))
+		node.second[observation] = choice
+	}
+	return choice
+}
+
+//compChoice will return the statistics of the comp moves in the node. Comp moves second in the root only after the user selected the card,
+//so all user moves in the root have the same observation
+func (node *mctsNode) compChoice() *mctsChoice {
+	if node.firstMover == game.Comp {
+		return node.first
+	}
+	return node.secondChoice(0)
+}
+
+//playerPayoff will convert the comp payoff to the payoff of the player
+func playerPayoff(player int, payoff float32) float32 {
+	if player == game.User {
+		return solverWin - payoff
+	}
+	return payoff
+}
+
+//randomSolverMove will return the random card from the mask with the random power, the last card always takes all power
+func randomSolverMove(cards uint8, power int8, rnd *rand.Rand) solverMove {
+	cardsNum := bits.OnesCount8(cards)
+	skipped := rnd.Intn(cardsNum)
+	move := solverMove{power: int(power)}
+	for i := 0; i < 8; i++ {
+		if cards&(1<<uint(i)) == 0 {
+			continue
+		}
+		if skipped == 0 {
+			move.card = i
+			break
+		}
+		skipped--
+	}
+	if cardsNum > 1 {
+		move.power = rnd.Intn(int(power) + 1)
+	}
+	return move
+}
+
+//rollout will play random moves of both players until the end of the game and return the comp payoff
+func (m *mctsSearch) rollout(state solverState) float32 {
+	for !state.isOver() {
+		compMove := randomSolverMove(state.compCards, state.compPower, m.rand)
+		userMove := randomSolverMove(state.userCards, state.userPower, m.rand)
+		state = m.solver.next(state, compMove, userMove)
+	}
+	return state.result()
+}
+
+//iterate will select the moves of both players down the tree, add the first new turn, evaluate it with the rollout and update the statistics on the way back.
+//Comp payoff of the iteration is returned
+func (m *mctsSearch) iterate(node *mctsNode) float32 {
+	if node.state.isOver() {
+		return node.state.result()
+	}
+	secondMover := game.Opponent(node.firstMover)
+	firstMove := node.first.selectMove(m.rand)
+	second := node.secondChoice(firstMove)
+	secondMove := second.selectMove(m.rand)
+	var moves [2]int
+	moves[node.firstMover] = firstMove
+	moves[secondMover] = secondMove
+
+	var payoff float32
+	if child, ok := node.children[moves]; ok {
+		payoff = m.iterate(child)
+	} else {
+		state := m.solver.next(node.state, node.moves[game.Comp][moves[game.Comp]], node.moves[game.User][moves[game.User]])
+		child = newMCTSNode(state, secondMover, solverMoves(state.compCards, state.compPower), solverMoves(state.userCards, state.userPower))
+		node.children[moves] = child
+		m.nodes++
+		payoff = m.rollout(state)
+	}
+	node.first.update(firstMove, playerPayoff(node.firstMover, payoff))
+	second.update(secondMove, playerPayoff(secondMover, payoff))
+	return payoff
+}
+
+//newMCTSSearch will create the search from the current state of the bot, seat is the comp index in the game.
+//User moves first in the root, if the user already selected the card
+func newMCTSSearch(compHand game.Hand, userHand game.Hand, seat int, rnd *rand.Rand) *mctsSearch {
+	state := newSolverState(compHand, userHand)
+	firstMover := game.Comp
+	if userHand.SelectedCard != -1 {
+		firstMover = game.User
+	}
+	return &mctsSearch{
+		solver: newSolver(compHand, userHand, seat),
+		root:   newMCTSNode(state, firstMover, solverMoves(state.compCards, state.compPower), userTurnMoves(userHand, state)),
+		rand:   rnd,
+	}
+}
+
+//run will iterate the search for mctsTimeLimit if it is set, otherwise for mctsIterations, and return the number of iterations
+func (m *mctsSearch) run() int {
+	deadline := time.Now().Add(mctsTimeLimit)
+	iterations := 0
+	//At least one iteration is needed to select the move
+	for iterations == 0 || (mctsTimeLimit > 0 && time.Now().Before(deadline)) || (mctsTimeLimit <= 0 && iterations < mctsIterations) {
+		m.iterate(m.root)
+		iterations++
+	}
+	Logger.Debug("mcts iterations =", iterations, "nodes =", m.nodes)
+	return iterations
+}
+
+//GetMCTSMove will return card number, power and the estimated payoff of the next comp move found by the Monte Carlo tree search, seat is the comp index in the game
+func GetMCTSMove(compHand game.Hand, userHand game.Hand, seat int, rnd *rand.Rand) (int, int, float32) {
+	m := newMCTSSearch(compHand, userHand, seat, rnd)
+	m.run()
+
+	choice := m.root.compChoice()
+	selected := 0
+	if gameRules.Simultaneous {
+		//Visits of the moves approximate the mixed strategy, so the user can't exploit the comp
+		sample := rnd.Intn(choice.visits)
+		for i, v := range choice.moveVisits {
+			sample -= v
+			if sample < 0 {
+				selected = i
+				break
+			}
+		}
+	} else {
+		//Most visited move is more reliable than the move with the best average payoff
+		for i, v := range choice.moveVisits {
+			if v > choice.moveVisits[selected] {
+				selected = i
+			}
+		}
+	}
+	move := m.root.moves[game.Comp][selected]
+	return move.card, move.power, float32(choice.moveValues[selected] / float64(choice.moveVisits[selected]))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+func TestMCTSHiddenPowerSelectedCard(t *testing.T) {
+	savedRules, savedIterations, savedTimeLimit := gameRules, mctsIterations, mctsTimeLimit
+	defer func() { gameRules, mctsIterations, mctsTimeLimit = savedRules, savedIterations, savedTimeLimit }()
+	gameRules = game.DefaultRules
+	gameRules.HiddenPower = true
+	mctsIterations, mctsTimeLimit = 500, 0
+	hands := testDeal(1)
+	for card := 1; card < len(hands[game.User].Cards); card++ {
+		//User moved first with the power hidden from the comp
+		g := game.New(hands[game.Comp], hands[game.User], game.User)
+		if err := g.Apply(game.Move{Player: game.User, Card: card, Power: 1}); err != nil {
+			t.Fatal(err)
+		}
+		compHand, userHand := g.Hand(game.Comp), g.Hand(game.User).HidePower()
+
+		m := newMCTSSearch(compHand, userHand, game.Comp, rand.New(rand.NewSource(1)))
+		iterations := m.run()
+		//Every iteration updates the comp statistics in the information set of the selected card
+		if visits := m.root.compChoice().visits; visits != iterations {
+			t.Errorf("card %v: comp choice has %v visits after %v iterations", card, visits, iterations)
+		}
+
+		compCard, compPower, _ := GetMCTSMove(compHand, userHand, game.Comp, rand.New(rand.NewSource(1)))
+		if err := g.Apply(game.Move{Player: game.Comp, Card: compCard, Power: compPower}); err != nil {
+			t.Errorf("card %v: comp move is not legal: %v", card, err)
+		}
+	}
+}
+
+func TestGetMCTSMoveSeed(t *testing.T) {
+	savedRules, savedIterations, savedTimeLimit := gameRules, mctsIterations, mctsTimeLimit
+	defer func() { gameRules, mctsIterations, mctsTimeLimit = savedRules, savedIterations, savedTimeLimit }()
+	mctsIterations, mctsTimeLimit = 500, 0
+	tests := []struct {
+		name         string
+		hiddenPower  bool
+		simultaneous bool
+	}{
+		{"default rules", false, false},
+		{"hidden power", true, false},
+		{"simultaneous moves", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameRules = game.DefaultRules
+			gameRules.HiddenPower, gameRules.Simultaneous = tt.hiddenPower, tt.simultaneous
+			//Same seed and iteration budget should give the same move
+			for seed := int64(1); seed <= 3; seed++ {
+				hands := testDeal(seed)
+				var moves [2][3]float32
+				for i := range moves {
+					card, power, payoff := GetMCTSMove(hands[game.Comp], hands[game.User], game.Comp, rand.New(rand.NewSource(seed)))
+					moves[i] = [3]float32{float32(card), float32(power), payoff}
+				}
+				if moves[0] != moves[1] {
+					t.Errorf("seed %v: moves %v and %v are different", seed, moves[0], moves[1])
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetMCTSMove(b *testing.B) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	gameRules = game.DefaultRules
+	hands := testDeal(1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetMCTSMove(hands[game.Comp], hands[game.User], game.Comp, rand.New(rand.NewSource(1)))
+	}
+}
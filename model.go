@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//Opponent model parameters
+const (
+	valueClasses  int = 3 //card value is classified as low, middle or high among the remaining cards of the player
+	powerBuckets  int = 5 //share of the remaining power spent on the card is split into buckets of the same size
+	modelMaxTurns int = game.MaxHandSize
+)
+
+//powerModel is the distribution of the power share spent by the player on the card by the turn number and the card value class
+type powerModel struct {
+	Moves  int                                            `json:"moves"`  //number of the observed moves
+	Counts [modelMaxTurns][valueClasses][powerBuckets]int `json:"counts"` //number of the observed moves by turn, value class and power bucket
+}
+
+//powerModels are the models of all players found in the game records by the player identity
+type powerModels map[string]*powerModel
+
+//opponentModels are the models used by the GA players to weight the opponent plans by the opponent identity
+var opponentModels = powerModels{}
+
+//loadPowerModels will read the models from the file, models are empty if the file doesn't exist yet
+func loadPowerModels(path string) (powerModels, error) {
+	models := powerModels{}
+	if path == "" {
+		return models, nil
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return models, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err := json.NewDecoder(file).Decode(&models); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return models, nil
+}
+
+//save will write the models to the file, file is replaced only after all models are written
+func (models powerModels) save(path string) error {
+	data, err := json.Marshal(models)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+//playerIdentity will return the key of the player model, players are identified by the name, player type is used for the players without the name
+func playerIdentity(name string, playerType string) string {
+	if name != "" {
+		return name
+	}
+	return playerType
+}
+
+//learn will add moves of both players from the game record to the models of their identities
+func (models powerModels) learn(record gameRecord) {
+	for seat, playerType := range record.Players {
+		identity := playerIdentity(record.Names[seat], playerType)
+		model, ok := models[identity]
+		if !ok {
+			model = &powerModel{}
+			models[identity] = model
+		}
+		hand := record.Hands[seat].hand()
+		for _, turn := range record.Turns {
+			card, power := turn.Cards[seat], turn.Powers[seat]
+			if card < 0 || card >= len(hand.Cards) || !hand.Cards[card].Playable || power < 0 || power > hand.Power {
+				break
+			}
+			model.observe(hand, card, power)
+			hand.Cards[card].Playable = false
+			hand.Power -= power
+		}
+	}
+}
+
+//opponentModel will return the model of the opponent identity, nil if there is no model
+func opponentModel(identity string) *powerModel {
+	return opponentModels[identity]
+}
+
+//moveContext will return the turn number and the value class of the card for the hand before the move
+func moveContext(hand game.Hand, card int) (int, int) {
+	turn := len(hand.Cards) - hand.PlayableCards()
+	if turn >= modelMaxTurns {
+		turn = modelMaxTurns - 1
+	}
+	//Rank of the card value among the playable cards, equal values share the average rank
+	lower, equal := 0, 0
+	for _, v := range hand.Cards {
+		if !v.Playable {
+			continue
+		}
+		if v.Value < hand.Cards[card].Value {
+			lower++
+		} else if v.Value == hand.Cards[card].Value {
+			equal++
+		}
+	}
+	return turn, (2*lower + equal - 1) * valueClasses / (2 * hand.PlayableCards())
+}
+
+//powerBucket will return the bucket of the power share, ok is false if there is no choice of power
+func powerBucket(hand game.Hand, power int) (int, bool) {
+	if hand.Power == 0 || hand.PlayableCards() == 1 {
+		return 0, false
+	}
+	bucket := power * powerBuckets / hand.Power
+	if bucket == powerBuckets {
+		bucket--
+	}
+	return bucket, true
+}
+
+//observe will count the move of the player with the hand
+func (model *powerModel) observe(hand game.Hand, card int, power int) {
+	bucket, ok := powerBucket(hand, power)
+	if !ok {
+		return
+	}
+	turn, class := moveContext(hand, card)
+	model.Counts[turn][class][bucket]++
+	model.Moves++
+}
+
+//probability will return the probability of the power bucket relative to the uniform choice of the bucket, counts are smoothed, so the unseen moves are still possible
+func (model *powerModel) probability(turn int, class int, bucket int) float32 {
+	total := 0
+	for _, v := range model.Counts[turn][class] {
+		total += v
+	}
+	return float32(model.Counts[turn][class][bucket]+1) * float32(powerBuckets) / float32(total+powerBuckets)
+}
+
+//weightPlans will set the weight of every opponent plan to the probability of the plan moves by the model, weights are not changed without the model
+func weightPlans(plans []opponentPlan, hand game.Hand, model *powerModel) {
+	if model == nil || model.Moves == 0 {
+		return
+	}
+	for i, plan := range plans {
+		planHand := hand.Copy()
+		weight := float32(1)
+		for j, card := range plan.order {
+			if bucket, ok := powerBucket(planHand, plan.power[j]); ok {
+				turn, class := moveContext(planHand, card)
+				weight *= model.probability(turn, class, bucket)
+			}
+			planHand.Cards[card].Playable = false
+			planHand.Power -= plan.power[j]
+		}
+		plans[i].weight = weight
+	}
+}
+
+//runLearn will build the power models from the game records and save them to the model file
+func runLearn(args []string) {
+	flags := flag.NewFlagSet("learn", flag.ExitOnError)
+	modelPath := flags.String("model", "opponents.json", "JSON file to save the power models to, the file is replaced")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kaart learn [-model file] <records.jsonl>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	models := powerModels{}
+	games := 0
+	for _, path := range flags.Args() {
+		records, err := readGameRecords(path)
+		if err != nil {
+			Logger.Fatal(err)
+		}
+		for _, record := range records {
+			models.learn(record)
+		}
+		games += len(records)
+	}
+	if err := models.save(*modelPath); err != nil {
+		Logger.Fatal(err)
+	}
+	var identities []string
+	for identity := range models {
+		identities = append(identities, identity)
+	}
+	sort.Strings(identities)
+	fmt.Printf("Learned from %v games:\n", games)
+	for _, identity := range identities {
+		fmt.Printf("%-12s %v moves\n", identity, models[identity].Moves)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//allPowerRecord will create the game record, where the comp plays the highest card with all power first and the user plays the cards in order without power
+func allPowerRecord(names [2]string, playerTypes [2]string) gameRecord {
+	hand := testGAHand(3, 6)
+	record := gameRecord{Players: playerTypes, Names: names, Hands: [2]handRecord{newHandRecord(hand), newHandRecord(hand)}}
+	for i, card := range []int{2, 0, 1} {
+		turn := turnRecord{Cards: [2]int{card, i}}
+		if i == 0 {
+			turn.Powers[game.Comp] = hand.Power
+		}
+		record.Turns = append(record.Turns, turn)
+	}
+	return record
+}
+
+func TestMoveContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []int
+		played    []int
+		card      int
+		wantTurn  int
+		wantClass int
+	}{
+		{"lowest card", []int{1, 2, 3, 4}, nil, 0, 0, 0},
+		{"middle card", []int{1, 2, 3, 4}, nil, 2, 0, 1},
+		{"highest card", []int{1, 2, 3, 4}, nil, 3, 0, 2},
+		{"highest card of the remaining cards", []int{1, 2, 3, 4}, []int{3}, 2, 1, 2},
+		{"played cards are not ranked", []int{5, 1, 2}, []int{0}, 1, 1, 0},
+		{"equal values share the rank", []int{2, 2, 2}, nil, 1, 0, 1},
+		{"single card is the lowest", []int{1, 2, 3}, []int{0, 2}, 1, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hand := testGAHand(len(tt.values), 0, tt.played...)
+			for i, v := range tt.values {
+				hand.Cards[i].Value = v
+			}
+			turn, class := moveContext(hand, tt.card)
+			if turn != tt.wantTurn || class != tt.wantClass {
+				t.Errorf("moveContext() = %v, %v, want %v, %v", turn, class, tt.wantTurn, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestPowerBucket(t *testing.T) {
+	tests := []struct {
+		name       string
+		hand       game.Hand
+		power      int
+		wantBucket int
+		wantOk     bool
+	}{
+		{"no power", testGAHand(4, 10), 0, 0, true},
+		{"small share", testGAHand(4, 10), 1, 0, true},
+		{"middle share", testGAHand(4, 10), 5, 2, true},
+		{"large share", testGAHand(4, 10), 9, 4, true},
+		{"all power is the last bucket", testGAHand(4, 10), 10, powerBuckets - 1, true},
+		{"no power left", testGAHand(4, 0), 0, 0, false},
+		{"last card takes all power", testGAHand(4, 10, 0, 1, 2), 10, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, ok := powerBucket(tt.hand, tt.power)
+			if bucket != tt.wantBucket || ok != tt.wantOk {
+				t.Errorf("powerBucket(%v) = %v, %v, want %v, %v", tt.power, bucket, ok, tt.wantBucket, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestLearnIdentity(t *testing.T) {
+	models := powerModels{}
+	models.learn(allPowerRecord([2]string{"alice", ""}, [2]string{playerHuman, playerRandom}))
+	tests := []struct {
+		name      string
+		identity  string
+		wantMoves int
+	}{
+		//Moves without the choice of power are not observed, comp has no power after the first move and the last card takes all power
+		{"named player is keyed by the name", "alice", 1},
+		{"player without the name is keyed by the type", playerRandom, 2},
+		{"type of the named player has no model", playerHuman, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moves := 0
+			if model, ok := models[tt.identity]; ok {
+				moves = model.Moves
+			}
+			if moves != tt.wantMoves {
+				t.Errorf("model of %q has %v moves, want %v", tt.identity, moves, tt.wantMoves)
+			}
+		})
+	}
+}
+
+func TestWeightPlans(t *testing.T) {
+	models := powerModels{}
+	for i := 0; i < 10; i++ {
+		models.learn(allPowerRecord([2]string{"alice", "bob"}, [2]string{playerHuman, playerHuman}))
+	}
+	hand := testGAHand(3, 6)
+	tests := []struct {
+		name  string
+		model *powerModel
+		check func(t *testing.T, plans []opponentPlan)
+	}{
+		{"plans have the same weight without the model", nil, func(t *testing.T, plans []opponentPlan) {
+			for _, v := range plans {
+				if v.weight != 1 {
+					t.Fatalf("plan %v %v has weight %v", v.order, v.power, v.weight)
+				}
+			}
+		}},
+		{"all power on the highest card has the highest weight", models["alice"], func(t *testing.T, plans []opponentPlan) {
+			maxWeight := float32(0)
+			for _, v := range plans {
+				if v.weight > maxWeight {
+					maxWeight = v.weight
+				}
+			}
+			//Plans with the power share in the same bucket are not told apart
+			for _, v := range plans {
+				if v.weight == maxWeight && v.order[0] != 2 {
+					t.Errorf("plan %v %v has the highest weight %v", v.order, v.power, v.weight)
+				}
+				if v.order[0] == 2 && v.power[0] == hand.Power && v.weight != maxWeight {
+					t.Errorf("plan %v %v with all power on the highest card has weight %v, highest weight is %v", v.order, v.power, v.weight, maxWeight)
+				}
+				if v.order[0] == 2 && v.power[0] == 0 && v.weight >= 1 {
+					t.Errorf("plan %v %v without power on the highest card has weight %v", v.order, v.power, v.weight)
+				}
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plans := opponentPlans(hand, rand.New(rand.NewSource(1)))
+			weightPlans(plans, hand, tt.model)
+			tt.check(t, plans)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//defaultAddress is the address of the game server
+const defaultAddress string = "localhost:7777"
+
+//Network message types
+const (
+	msgWelcome string = "welcome" //server: seat and rules of the client
+	msgState   string = "state"   //server: table visible to the client, client should send the move if it is to move
+	msgMove    string = "move"    //client: selected card and power
+	msgCommit  string = "commit"  //server: table for the simultaneous turn, client: commitment of the move
+	msgReveal  string = "reveal"  //server: commitment of the opponent, client: committed move and nonce
+	msgBattle  string = "battle"  //server: hands with revealed moves and the nonce of the opponent
+	msgResult  string = "result"  //server: final table, the winner and the reason of the forfeit
+	msgError   string = "error"   //server: incorrect move or the game is aborted
+)
+
+//netMessage is a single message of the game protocol, every message is one JSON line
+type netMessage struct {
+	Type       string        `json:"type"`
+	Seat       int           `json:"seat"`
+	Rules      *game.Rules   `json:"rules,omitempty"`
+	Hands      *[2]game.Hand `json:"hands,omitempty"` //comp and user hands visible to the client
+	ToMove     int           `json:"to_move"`
+	Winner     int           `json:"winner"`
+	Card       int           `json:"card"`
+	Power      int           `json:"power"`
+	Commitment string        `json:"commitment,omitempty"` //hex encoded commitment
+	Nonce      []byte        `json:"nonce,omitempty"`
+	Text       string        `json:"text,omitempty"`
+}
+
+//netConn sends and receives messages over the network connection
+type netConn struct {
+	conn    net.Conn
+	decoder *json.Decoder
+	writer  *bufio.Writer
+	timeout time.Duration //maximum time to send or receive the message, 0 to wait forever
+}
+
+func newNetConn(conn net.Conn, timeout time.Duration) *netConn {
+	return &netConn{
+		conn:    conn,
+		decoder: json.NewDecoder(bufio.NewReader(conn)),
+		writer:  bufio.NewWriter(conn),
+		timeout: timeout,
+	}
+}
+
+//send will write the message as a JSON line
+func (c *netConn) send(message netMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if c.timeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	if _, err := c.writer.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return c.writer.Flush()
+}
+
+//receive will read the next message, message type should be one of the expected types
+func (c *netConn) receive(expectedTypes ...string) (netMessage, error) {
+	var message netMessage
+	if c.timeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	if err := c.decoder.Decode(&message); err != nil {
+		return message, err
+	}
+	for _, v := range expectedTypes {
+		if message.Type == v {
+			return message, nil
+		}
+	}
+	if message.Type == msgError {
+		return message, fmt.Errorf("%v", message.Text)
+	}
+	return message, fmt.Errorf("unexpected message %q, expected one of %v", message.Type, expectedTypes)
+}
+
+//encodeCommitment will convert commitment to the hex string
+func encodeCommitment(commitment game.Commitment) string {
+	return hex.EncodeToString(commitment[:])
+}
+
+//decodeCommitment will convert the hex string to the commitment
+func decodeCommitment(value string) (game.Commitment, error) {
+	var commitment game.Commitment
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return commitment, fmt.Errorf("incorrect commitment: %v", err)
+	}
+	if len(data) != len(commitment) {
+		return commitment, fmt.Errorf("incorrect commitment size %v, should be %v", len(data), len(commitment))
+	}
+	copy(commitment[:], data)
+	return commitment, nil
+}
+
+//clientHands will return comp and user hands visible to the player, opponent hand is the same as in the state of the local player
+func clientHands(g *game.Game, player int) *[2]game.Hand {
+	var hands [2]game.Hand
+	state := playerState(g, player)
+	hands[player] = state.Hand
+	hands[game.Opponent(player)] = state.Opponent
+	return &hands
+}
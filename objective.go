@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//Objectives of the GA player, every objective is scaled from 0 to 1
+const (
+	objectiveWin      string = "win"      //probability to win the game
+	objectiveWinDraw  string = "win-draw" //probability to win or draw the game
+	objectiveHealth   string = "health"   //expected health differential at the end of the game
+	objectiveMaximin  string = "maximin"  //health differential against the worst opponent plan
+	objectiveQuantile string = "quantile" //health differential at the robustQuantile of the opponent plans from the worst one
+	objectiveBlend    string = "blend"    //expected health differential blended with the worst one, riskWeight is the weight of the worst one
+)
+
+//objectives is the list of all available objectives for the command line help
+var objectives = []string{objectiveWin, objectiveWinDraw, objectiveHealth, objectiveMaximin, objectiveQuantile, objectiveBlend}
+
+//defaultObjective counts draws as wins, like the original fitness of the GA player
+const defaultObjective string = objectiveWinDraw
+
+//Objective parameters
+var (
+	riskWeight     float32 = 0.5 //weight of the worst health differential in the blend objective, 0 is the same as health objective, 1 is the same as maximin
+	robustQuantile float32 = 0.1 //share of the worst opponent plans ignored by the quantile objective, 0 is the same as maximin
+)
+
+//fractionFlag is the command line flag for the parameter from 0 to 1
+type fractionFlag float32
+
+//outcomes is the summary of the game results for all opponent plans, every game is counted with the weight of the opponent plan
+type outcomes struct {
+	games     float64
+	wins      float64
+	draws     float64
+	health    float64   //sum of the health differentials
+	histogram []float64 //weight of the games for every health differential
+}
+
+//String will return the value of the flag
+func (fraction *fractionFlag) String() string {
+	return strconv.FormatFloat(float64(*fraction), 'g', -1, 32)
+}
+
+//Set will parse the value of the flag, it should be from 0 to 1
+func (fraction *fractionFlag) Set(value string) error {
+	newFraction, err := strconv.ParseFloat(value, 32)
+	if err != nil || newFraction < 0 || newFraction > 1 {
+		return fmt.Errorf("incorrect value %q, it should be from 0 to 1", value)
+	}
+	*fraction = fractionFlag(newFraction)
+	return nil
+}
+
+//validateObjective will check that the objective is known
+func validateObjective(objective string) error {
+	for _, v := range objectives {
+		if objective == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown objective %q, available objectives are %v", objective, objectives)
+}
+
+//parsePlayerType will split the player type to the type and the objective, objective is set after the colon only for the GA player
+func parsePlayerType(playerType string) (string, string, error) {
+	parts := strings.SplitN(playerType, ":", 2)
+	if len(parts) == 1 {
+		return playerType, defaultObjective, nil
+	}
+	if parts[0] != playerGA {
+		return parts[0], "", fmt.Errorf("objective is supported only by the %v player, got %q", playerGA, playerType)
+	}
+	return parts[0], parts[1], validateObjective(parts[1])
+}
+
+//playerUsage is the command line help for the player type
+func playerUsage(seat string) string {
+	return fmt.Sprintf("%v, one of %v, objective of the %v player is set as %v:<objective>, one of %v, %v is used if it is not set", seat, playerTypes, playerGA, playerGA, objectives, defaultObjective)
+}
+
+//robustObjective will return the share of the worst opponent plans ignored by the robust objective, ok is false for the objectives depending on all plans
+func robustObjective(objective string) (quantile float32, ok bool) {
+	switch objective {
+	case objectiveMaximin:
+		return 0, true
+	case objectiveQuantile:
+		return robustQuantile, true
+	}
+	return 0, false
+}
+
+//newOutcomes will create the empty summary for the hands
+func newOutcomes(compHand game.Hand, userHand game.Hand) outcomes {
+	return outcomes{histogram: make([]float64, compHand.Health+userHand.Health+1)}
+}
+
+//add will add the game result for the final health of the players with the weight and return the health differential of the game.
+//Health differential is shifted by the initial health of the opponent, so it is 0 when the comp lost all health and the opponent kept all health
+func (o *outcomes) add(compHealth int, userHealth int, userHand game.Hand, weight float32) int {
+	switch {
+	case compHealth > userHealth:
+		o.wins += float64(weight)
+	case compHealth == userHealth:
+		o.draws += float64(weight)
+	}
+	if compHealth < 0 {
+		compHealth = 0
+	}
+	if userHealth < 0 {
+		userHealth = 0
+	}
+	health := compHealth - userHealth + userHand.Health
+	o.histogram[health] += float64(weight)
+	o.health += float64(health) * float64(weight)
+	o.games += float64(weight)
+	return health
+}
+
+//scale will convert the health differential to the fitness from 0 to 1
+func (o outcomes) scale(health float64) float32 {
+	return float32(health / float64(len(o.histogram)-1))
+}
+
+//worstHealth will return the lowest health differential with the weight of the games not above it more than worstWeight.
+//Best health differential of the games is returned, if worstWeight is not less than the weight of all games
+func (o outcomes) worstHealth(worstWeight float64) float64 {
+	weight := float64(0)
+	best := 0
+	for i, v := range o.histogram {
+		if v == 0 {
+			continue
+		}
+		best = i
+		weight += v
+		if weight > worstWeight {
+			return float64(i)
+		}
+	}
+	return float64(best)
+}
+
+//score will return the fitness for the objective, totalWeight is the weight of all opponent plans, it is more than the weight of the games for the pruned chromosome
+func (o outcomes) score(objective string, totalWeight float64) float32 {
+	if o.games == 0 {
+		return 0
+	}
+	if quantile, ok := robustObjective(objective); ok {
+		return o.scale(o.worstHealth(float64(quantile) * totalWeight))
+	}
+	switch objective {
+	case objectiveWin:
+		return float32(o.wins / o.games)
+	case objectiveHealth:
+		return o.scale(o.health / o.games)
+	case objectiveBlend:
+		return (1-riskWeight)*o.scale(o.health/o.games) + riskWeight*o.scale(o.worstHealth(0))
+	}
+	return float32((o.wins + o.draws) / o.games)
+}
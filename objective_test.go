@@ -0,0 +1,133 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestWorstHealth(t *testing.T) {
+	//Total weight of the games is 4
+	histogram := []float64{0, 0.5, 0, 1.5, 2, 0}
+	tests := []struct {
+		name        string
+		worstWeight float64
+		want        float64
+	}{
+		{"quantile 0 is the worst game", 0, 1},
+		{"weight of the worst game is ignored", 0.5, 3},
+		{"weight inside the games", 1, 3},
+		{"weight of all games but the best ones", 2, 4},
+		{"quantile 1 is the best game", 4, 4},
+		{"weight above all games is the best game", 5, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := outcomes{histogram: histogram}
+			if got := o.worstHealth(tt.worstWeight); got != tt.want {
+				t.Errorf("worstHealth(%v) = %v, want %v", tt.worstWeight, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreQuantile(t *testing.T) {
+	savedQuantile := robustQuantile
+	defer func() { robustQuantile = savedQuantile }()
+	//Games are weighted like the opponent plans, health differential is from 0 to 4
+	o := outcomes{histogram: make([]float64, 5)}
+	userHand := testGAHand(1, 0)
+	userHand.Health = 2
+	o.add(1, 2, userHand, 0.25)
+	o.add(2, 1, userHand, 1.5)
+	o.add(2, 0, userHand, 0.25)
+	tests := []struct {
+		name      string
+		objective string
+		quantile  float32
+		want      float32
+	}{
+		{"maximin is the worst game", objectiveMaximin, 0, 0.25},
+		{"quantile 0 is the same as maximin", objectiveQuantile, 0, 0.25},
+		{"quantile skips the weight of the worst game", objectiveQuantile, 0.125, 0.75},
+		{"quantile 1 is the best game", objectiveQuantile, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			robustQuantile = tt.quantile
+			if got := o.score(tt.objective, o.games); got != tt.want {
+				t.Errorf("score(%v) with quantile %v = %v, want %v", tt.objective, tt.quantile, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	savedRiskWeight := riskWeight
+	defer func() { riskWeight = savedRiskWeight }()
+	//Games are weighted like the opponent plans, health differential is from 0 to 4
+	o := outcomes{histogram: make([]float64, 5)}
+	userHand := testGAHand(1, 0)
+	userHand.Health = 2
+	o.add(1, 2, userHand, 0.25)
+	o.add(2, 2, userHand, 0.75)
+	o.add(3, 1, userHand, 1)
+	tests := []struct {
+		name       string
+		objective  string
+		riskWeight float32
+		want       float32
+	}{
+		{"win is the weight of the won games", objectiveWin, 0.5, 0.5},
+		{"win-draw adds the weight of the draws", objectiveWinDraw, 0.5, 0.875},
+		{"health is the average health differential", objectiveHealth, 0.5, 0.71875},
+		{"blend mixes the average and the worst health differential", objectiveBlend, 0.5, 0.484375},
+		{"blend without the risk is the health", objectiveBlend, 0, 0.71875},
+		{"blend with the full risk is the worst game", objectiveBlend, 1, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			riskWeight = tt.riskWeight
+			if got := o.score(tt.objective, o.games); got != tt.want {
+				t.Errorf("score(%v) with risk weight %v = %v, want %v", tt.objective, tt.riskWeight, got, tt.want)
+			}
+		})
+	}
+	for _, v := range objectives {
+		if got := newOutcomes(userHand, userHand).score(v, 0); got != 0 {
+			t.Errorf("score(%v) without games = %v, want 0", v, got)
+		}
+	}
+}
+
+func TestParsePlayerType(t *testing.T) {
+	tests := []struct {
+		playerType    string
+		wantType      string
+		wantObjective string
+		wantErr       bool
+	}{
+		{playerGA, playerGA, defaultObjective, false},
+		{playerRandom, playerRandom, defaultObjective, false},
+		{"ga:maximin", playerGA, objectiveMaximin, false},
+		{"ga:blend", playerGA, objectiveBlend, false},
+		{"ga:luck", playerGA, "luck", true},
+		{"ga:", playerGA, "", true},
+		{"random:win", playerRandom, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.playerType, func(t *testing.T) {
+			playerType, objective, err := parsePlayerType(tt.playerType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePlayerType error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if playerType != tt.wantType || objective != tt.wantObjective {
+				t.Errorf("parsePlayerType = %v, %v, want %v, %v", playerType, objective, tt.wantType, tt.wantObjective)
+			}
+		})
+	}
+	for _, v := range []string{"ga:luck", "random:win"} {
+		if _, err := newPlayer(v, rand.New(rand.NewSource(1))); err == nil {
+			t.Errorf("newPlayer(%q) error = nil, want error", v)
+		}
+	}
+}
@@ -1,5 +1,17 @@
 package main
 
+import "sync"
+
+//permutationsCache keeps the generated permutation tables, tables are shared between all callers and must not be modified
+var permutationsCache = struct {
+	sync.Mutex
+	orders map[int][][]int    //permutations of 0..n-1 by n
+	sums   map[[2]int][][]int //permutations for sum by k and the sum
+}{
+	orders: make(map[int][][]int),
+	sums:   make(map[[2]int][][]int),
+}
+
 func nextPerm(p []int) {
 	for i := len(p) - 1; i >= 0; i-- {
 		if i == 0 || p[i] < len(p)-i-1 {
@@ -18,8 +30,11 @@ func getPerm(orig, p []int) []int {
 	return result
 }
 
-//GetAllPermutations will generate all possible permutations of specific int slice
+//GetAllPermutations will generate all possible permutations of specific int slice, empty slice has the single empty permutation
 func GetAllPermutations(orig []int) [][]int {
+	if len(orig) == 0 {
+		return [][]int{{}}
+	}
 	var permutations [][]int
 	for p := make([]int, len(orig)); p[0] < len(p); nextPerm(p) {
 		permutations = append(permutations, getPerm(orig, p))
@@ -27,8 +42,66 @@ func GetAllPermutations(orig []int) [][]int {
 	return permutations
 }
 
-//GetAllPermutationsForSum will generate all possible permutations for k elements, where sum of all elements equal to totalSum
+//CachedPermutations will return all permutations of numbers 0..n-1, table is generated once and must not be modified
+func CachedPermutations(n int) [][]int {
+	permutationsCache.Lock()
+	defer permutationsCache.Unlock()
+	if permutations, ok := permutationsCache.orders[n]; ok {
+		return permutations
+	}
+	orig := make([]int, n)
+	for i := range orig {
+		orig[i] = i
+	}
+	permutations := GetAllPermutations(orig)
+	permutationsCache.orders[n] = permutations
+	return permutations
+}
+
+//CountPermutations will return the number of permutations of n elements
+func CountPermutations(n int) int {
+	count := 1
+	for i := 2; i <= n; i++ {
+		count *= i
+	}
+	return count
+}
+
+//CountPermutationsForSum will return the number of permutations for k elements with the sum equal to totalSum, it is binomial coefficient C(totalSum+k-1, k-1)
+func CountPermutationsForSum(k, totalSum int) int {
+	//No elements can have only the zero sum
+	if k == 0 && totalSum != 0 {
+		return 0
+	}
+	count := 1
+	for i := 1; i < k; i++ {
+		count = count * (totalSum + i) / i
+	}
+	return count
+}
+
+//CachedPermutationsForSum will return all permutations for k elements with the sum equal to totalSum, table is generated once and must not be modified
+func CachedPermutationsForSum(k, totalSum int) [][]int {
+	permutationsCache.Lock()
+	defer permutationsCache.Unlock()
+	key := [2]int{k, totalSum}
+	if permutations, ok := permutationsCache.sums[key]; ok {
+		return permutations
+	}
+	permutations := GetAllPermutationsForSum(k, totalSum)
+	permutationsCache.sums[key] = permutations
+	return permutations
+}
+
+//GetAllPermutationsForSum will generate all possible permutations for k elements, where sum of all elements equal to totalSum.
+//No elements have the single empty permutation for the zero sum and no permutations for other sums
 func GetAllPermutationsForSum(k, totalSum int) [][]int {
+	if k == 0 {
+		if totalSum == 0 {
+			return [][]int{{}}
+		}
+		return nil
+	}
 	var result [][]int
 	var output []int
 	getPermutationsForSum(k-1, 0, totalSum, output, &result)
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+func TestCountPermutations(t *testing.T) {
+	for n := 0; n <= 6; n++ {
+		orig := make([]int, n)
+		for i := range orig {
+			orig[i] = i
+		}
+		permutations := GetAllPermutations(orig)
+		if len(permutations) != CountPermutations(n) {
+			t.Errorf("CountPermutations(%v) = %v, generated %v permutations", n, CountPermutations(n), len(permutations))
+		}
+		seen := make(map[string]bool)
+		for _, v := range permutations {
+			if len(v) != n {
+				t.Fatalf("permutation %v of %v elements has length %v", v, n, len(v))
+			}
+			key := fmt.Sprint(v)
+			if seen[key] {
+				t.Errorf("permutation %v of %v elements is generated twice", v, n)
+			}
+			seen[key] = true
+		}
+	}
+}
+
+func TestCountPermutationsForSum(t *testing.T) {
+	for k := 0; k <= 6; k++ {
+		for sum := 0; sum <= 12; sum++ {
+			permutations := GetAllPermutationsForSum(k, sum)
+			if got := CountPermutationsForSum(k, sum); got != len(permutations) {
+				t.Errorf("CountPermutationsForSum(%v, %v) = %v, generated %v permutations", k, sum, got, len(permutations))
+			}
+			seen := make(map[string]bool)
+			for _, v := range permutations {
+				if len(v) != k {
+					t.Fatalf("permutation %v for %v elements has length %v", v, k, len(v))
+				}
+				total := 0
+				for _, p := range v {
+					if p < 0 {
+						t.Errorf("permutation %v has negative element", v)
+					}
+					total += p
+				}
+				if total != sum {
+					t.Errorf("permutation %v has sum %v, want %v", v, total, sum)
+				}
+				key := fmt.Sprint(v)
+				if seen[key] {
+					t.Errorf("permutation %v is generated twice", v)
+				}
+				seen[key] = true
+			}
+		}
+	}
+}
+
+func TestGetAllPermutationsForSumEmpty(t *testing.T) {
+	tests := []struct {
+		name     string
+		totalSum int
+		want     int
+	}{
+		{"zero sum has the empty permutation", 0, 1},
+		{"positive sum has no permutations", 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetAllPermutationsForSum(0, tt.totalSum); len(got) != tt.want {
+				t.Errorf("GetAllPermutationsForSum(0, %v) = %v, want %v permutations", tt.totalSum, got, tt.want)
+			}
+			if got := CachedPermutationsForSum(0, tt.totalSum); len(got) != tt.want {
+				t.Errorf("CachedPermutationsForSum(0, %v) = %v, want %v permutations", tt.totalSum, got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkGetAllPermutationsForSum(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetAllPermutationsForSum(game.DefaultRules.HandSize, game.DefaultRules.MaxPower)
+	}
+}
+
+func BenchmarkCachedPermutationsForSum(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		CachedPermutationsForSum(game.DefaultRules.HandSize, game.DefaultRules.MaxPower)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//Player types
+const (
+	playerHuman  string = "human"
+	playerGA     string = "ga"
+	playerSolver string = "solver"
+	playerRandom string = "random"
+	playerGreedy string = "greedy"
+	playerMCTS   string = "mcts"
+)
+
+//playerTypes is the list of all available player types for the command line help
+var playerTypes = []string{playerHuman, playerGA, playerSolver, playerRandom, playerGreedy, playerMCTS}
+
+//State is the game state visible to the player who should move
+type State struct {
+	Seat     int       //index of the player in the game
+	Hand     game.Hand //hand of the player
+	Opponent game.Hand //hand of the opponent, selected card is set if the opponent moved first, selected power is game.HiddenPower in the hidden power mode
+}
+
+//Player will select card and power for the next move
+type Player interface {
+	ChooseMove(state State) (int, int)
+}
+
+//HumanPlayer asks the user for the move in the terminal
+type HumanPlayer struct{}
+
+//GAPlayer selects the move with the genetic algorithm
+type GAPlayer struct {
+	rand      *rand.Rand
+	objective string           //objective of the plans fitness
+	model     *powerModel      //power model of the opponent, nil if there is no model
+	notes     *strings.Builder //explained plans since the last battle, nil if the plans are not explained
+}
+
+//SolverPlayer selects the move with the exhaustive game tree search, next turns are evaluated according to solverMode
+type SolverPlayer struct {
+	rand *rand.Rand
+}
+
+//RandomPlayer selects random card and random power
+type RandomPlayer struct {
+	rand *rand.Rand
+}
+
+//GreedyPlayer tries to win the current battle with the least power
+type GreedyPlayer struct{}
+
+//MCTSPlayer selects the move with the Monte Carlo tree search
+type MCTSPlayer struct {
+	rand *rand.Rand
+}
+
+//newPlayer will create the player of the specific type, all random decisions of the player are taken from rnd.
+//GA player type can have the objective after the colon, e.g. ga:maximin
+func newPlayer(playerType string, rnd *rand.Rand) (Player, error) {
+	playerType, objective, err := parsePlayerType(playerType)
+	if err != nil {
+		return nil, err
+	}
+	switch playerType {
+	case playerHuman:
+		return HumanPlayer{}, nil
+	case playerGA:
+		player := GAPlayer{rand: rnd, objective: objective}
+		if explainPlansNumber > 0 {
+			player.notes = &strings.Builder{}
+		}
+		return player, nil
+	case playerSolver:
+		if gameRules.HandSize > solverMaxHandSize {
+			return nil, fmt.Errorf("%v player supports hands up to %v cards, hand size is %v", playerSolver, solverMaxHandSize, gameRules.HandSize)
+		}
+		if solverMode == solverEquilibrium && gameRules.HandSize > solverEquilibriumHands {
+			return nil, fmt.Errorf("%v player in %v mode supports hands up to %v cards, hand size is %v", playerSolver, solverModeNames[solverMode], solverEquilibriumHands, gameRules.HandSize)
+		}
+		return SolverPlayer{rand: rnd}, nil
+	case playerRandom:
+		return RandomPlayer{rand: rnd}, nil
+	case playerGreedy:
+		return GreedyPlayer{}, nil
+	case playerMCTS:
+		return MCTSPlayer{rand: rnd}, nil
+	}
+	return nil, fmt.Errorf("unknown player type %q, available types are %v", playerType, playerTypes)
+}
+
+//lastCardMove will return the only playable card with all remaining power, ok is false if there are more playable cards
+func lastCardMove(hand game.Hand) (cardNumber int, cardPower int, ok bool) {
+	if hand.PlayableCards() != 1 {
+		return 0, 0, false
+	}
+	for i, v := range hand.Cards {
+		if v.Playable {
+			cardNumber = i
+		}
+	}
+	return cardNumber, hand.Power, true
+}
+
+//ChooseMove will ask the user for the card and power in the terminal UI or at the prompts
+func (HumanPlayer) ChooseMove(state State) (int, int) {
+	if terminal != nil {
+		return terminal.chooseMove(state)
+	}
+	return processUserTurn(state)
+}
+
+//ChooseMove will run the genetic algorithm for the move
+func (p GAPlayer) ChooseMove(state State) (int, int) {
+	if cardNumber, cardPower, ok := lastCardMove(state.Hand); ok {
+		return cardNumber, cardPower
+	}
+	chromosomes := evolvePlans(context.Background(), state.Hand, state.Opponent, state.Seat, p.objective, p.model, p.rand)
+	plan := selectPlan(chromosomes, p.rand)
+	//Plans are shown only after the battle, so the opponent doesn't see the move before own one
+	if p.notes != nil {
+		p.notes.WriteString(explainPlans(state.Seat, p.objective, chromosomes, plan, explainPlansNumber))
+	}
+	return plan.genes[0].order, plan.genes[0].power
+}
+
+//takeNotes will return the explained plans since the last call and clear them
+func (p GAPlayer) takeNotes() string {
+	if p.notes == nil {
+		return ""
+	}
+	notes := p.notes.String()
+	p.notes.Reset()
+	return notes
+}
+
+//ChooseMove will search the game tree for the move
+func (p SolverPlayer) ChooseMove(state State) (int, int) {
+	if cardNumber, cardPower, ok := lastCardMove(state.Hand); ok {
+		return cardNumber, cardPower
+	}
+	return GetSolverMove(state.Hand, state.Opponent, state.Seat, p.rand)
+}
+
+//ChooseMove will select random playable card and random power
+func (p RandomPlayer) ChooseMove(state State) (int, int) {
+	if cardNumber, cardPower, ok := lastCardMove(state.Hand); ok {
+		return cardNumber, cardPower
+	}
+	var playableCards []int
+	for i, v := range state.Hand.Cards {
+		if v.Playable {
+			playableCards = append(playableCards, i)
+		}
+	}
+	return playableCards[p.rand.Intn(len(playableCards))], p.rand.Intn(state.Hand.Power + 1)
+}
+
+//ChooseMove will beat the opponent card with the least power if the opponent moved first, hidden power is expected to be the equal share,
+//otherwise will play the card with the highest damage with the equal share of the power
+func (GreedyPlayer) ChooseMove(state State) (int, int) {
+	if cardNumber, cardPower, ok := lastCardMove(state.Hand); ok {
+		return cardNumber, cardPower
+	}
+	hand := state.Hand
+	opponent := state.Opponent
+
+	if opponent.SelectedCard == -1 {
+		bestCard := -1
+		for i, v := range hand.Cards {
+			if v.Playable && (bestCard == -1 || v.Damage > hand.Cards[bestCard].Damage) {
+				bestCard = i
+			}
+		}
+		return bestCard, hand.Power / hand.PlayableCards()
+	}
+
+	opponentPower := opponent.SelectedPower
+	if opponentPower == game.HiddenPower {
+		//Expect the opponent to use the equal share of the power
+		opponentPower = opponent.Power / opponent.PlayableCards()
+	}
+	opponentAttack := game.Attack(opponent.Cards[opponent.SelectedCard], opponentPower)
+	bestCard, bestPower := -1, 0
+	weakestCard := -1
+	for i, v := range hand.Cards {
+		if !v.Playable {
+			continue
+		}
+		if weakestCard == -1 || v.Value < hand.Cards[weakestCard].Value {
+			weakestCard = i
+		}
+		for power := 0; power <= hand.Power; power++ {
+			if !game.WinsBattle(state.Seat, game.Attack(v, power), opponentAttack) {
+				continue
+			}
+			//Prefer higher damage, then lower power
+			if bestCard == -1 || v.Damage > hand.Cards[bestCard].Damage || (v.Damage == hand.Cards[bestCard].Damage && power < bestPower) {
+				bestCard, bestPower = i, power
+			}
+			break
+		}
+	}
+	if bestCard == -1 {
+		//Battle can't be won, so sacrifice the weakest card without power
+		return weakestCard, 0
+	}
+	return bestCard, bestPower
+}
+
+//ChooseMove will search the tree of the next turns with random playouts for the move
+func (p MCTSPlayer) ChooseMove(state State) (int, int) {
+	if cardNumber, cardPower, ok := lastCardMove(state.Hand); ok {
+		return cardNumber, cardPower
+	}
+	cardNumber, cardPower, _ := GetMCTSMove(state.Hand, state.Opponent, state.Seat, p.rand)
+	return cardNumber, cardPower
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+func TestGreedyPlayer(t *testing.T) {
+	//selected will return the opponent hand with the selected card and power
+	selected := func(hand game.Hand, card int, power int) game.Hand {
+		hand.SelectedCard, hand.SelectedPower = card, power
+		return hand
+	}
+	damageHand := func(power int, cards ...game.Card) game.Hand {
+		hand := game.Hand{Health: 10, Power: power, SelectedCard: -1}
+		for _, v := range cards {
+			v.Playable = true
+			hand.Cards = append(hand.Cards, v)
+		}
+		return hand
+	}
+	tests := []struct {
+		name      string
+		seat      int
+		hand      game.Hand
+		opponent  game.Hand
+		wantCard  int
+		wantPower int
+	}{
+		{"first move plays the highest damage with the equal share", game.User, testGAHand(4, 10), testGAHand(4, 10), 3, 2},
+		{"first move skips the played cards", game.Comp, testGAHand(4, 10, 3), testGAHand(4, 10), 2, 3},
+		{"last card gets all power", game.User, testGAHand(4, 7, 0, 1, 2), selected(testGAHand(4, 10), 3, 10), 3, 7},
+		//Attack of the opponent is 2*(2+1)=6, the card 4 wins with 4*(1+1)=8
+		{"highest damage with the least power", game.User, testGAHand(4, 10), selected(testGAHand(4, 10), 1, 2), 3, 1},
+		//Attack of the opponent is 4*(1+1)=8, comp wins the tie
+		{"comp wins the tie", game.Comp, testGAHand(4, 10), selected(testGAHand(4, 10), 3, 1), 3, 1},
+		{"user needs more attack than the tie", game.User, testGAHand(4, 10), selected(testGAHand(4, 10), 3, 1), 3, 2},
+		{"played strongest card", game.User, testGAHand(4, 10, 3), selected(testGAHand(4, 10), 1, 2), 2, 2},
+		{"strongest winning card needs more power", game.User, damageHand(10, game.Card{Value: 4, Damage: 1}, game.Card{Value: 1, Damage: 6}), selected(testGAHand(4, 10), 1, 2), 1, 6},
+		{"strongest card can't win", game.User, damageHand(5, game.Card{Value: 4, Damage: 1}, game.Card{Value: 1, Damage: 6}), selected(testGAHand(4, 10), 1, 2), 0, 1},
+		{"same damage with less power", game.User, damageHand(10, game.Card{Value: 2, Damage: 5}, game.Card{Value: 4, Damage: 5}), selected(testGAHand(4, 10), 1, 2), 1, 1},
+		//Opponent is expected to use 9/3=3 power, so the attack is 2*(3+1)=8
+		{"hidden power is the equal share", game.User, testGAHand(4, 10), selected(testGAHand(4, 9, 0), 1, game.HiddenPower), 3, 2},
+		{"battle can't be won", game.User, testGAHand(4, 0), selected(testGAHand(4, 10), 1, 2), 0, 0},
+		{"weakest playable card is sacrificed", game.User, testGAHand(4, 0, 0), selected(testGAHand(4, 10), 1, 2), 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card, power := GreedyPlayer{}.ChooseMove(State{Seat: tt.seat, Hand: tt.hand, Opponent: tt.opponent})
+			if card != tt.wantCard || power != tt.wantPower {
+				t.Errorf("ChooseMove = %v, %v, want %v, %v", card, power, tt.wantCard, tt.wantPower)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//gameRecord is a single game stored as one line in the JSON Lines file
+type gameRecord struct {
+	Seed       int64         `json:"seed"`
+	Players    [2]string     `json:"players"` //player types in the comp and user seats
+	Names      [2]string     `json:"names"`   //player names in the comp and user seats, empty if the name is not set
+	Hands      [2]handRecord `json:"hands"`   //starting hands of the comp and user
+	FirstMover int           `json:"first_mover"`
+	Turns      []turnRecord  `json:"turns"`
+	Winner     int           `json:"winner"`
+	Rules      *game.Rules   `json:"rules,omitempty"` //rules of the game, current rules are used for the records without the rules
+}
+
+//handRecord is a starting hand of the player
+type handRecord struct {
+	Health int          `json:"health"`
+	Power  int          `json:"power"`
+	Cards  []cardRecord `json:"cards"`
+}
+
+//cardRecord is a single card of the starting hand
+type cardRecord struct {
+	Name   string `json:"name"`
+	Value  int    `json:"value"`
+	Damage int    `json:"damage"`
+}
+
+//turnRecord is a moves of both players in the single turn and health after the battle
+type turnRecord struct {
+	FirstMover int    `json:"first_mover"`
+	Cards      [2]int `json:"cards"`
+	Powers     [2]int `json:"powers"`
+	Health     [2]int `json:"health"`
+}
+
+func newHandRecord(hand game.Hand) handRecord {
+	record := handRecord{Health: hand.Health, Power: hand.Power}
+	for _, v := range hand.Cards {
+		record.Cards = append(record.Cards, cardRecord{Name: v.Name, Value: v.Value, Damage: v.Damage})
+	}
+	return record
+}
+
+//hand will convert hand record to the hand with all cards playable
+func (record handRecord) hand() game.Hand {
+	hand := game.Hand{Health: record.Health, Power: record.Power, SelectedCard: -1}
+	for _, v := range record.Cards {
+		hand.Cards = append(hand.Cards, game.Card{Name: v.Name, Value: v.Value, Damage: v.Damage, Playable: true})
+	}
+	return hand
+}
+
+//newGameRecord will create record with the starting state of the new game
+func newGameRecord(seed int64, playerTypes [2]string, names [2]string, g *game.Game) gameRecord {
+	rules := gameRules
+	return gameRecord{
+		Seed:       seed,
+		Players:    playerTypes,
+		Names:      names,
+		Hands:      [2]handRecord{newHandRecord(g.Hand(game.Comp)), newHandRecord(g.Hand(game.User))},
+		FirstMover: g.FirstMover(),
+		Rules:      &rules,
+	}
+}
+
+//finish will add all played turns and the winner of the finished game to the record
+func (record *gameRecord) finish(g *game.Game) {
+	record.Turns = nil
+	for _, battle := range g.Battles() {
+		var turn turnRecord
+		turn.FirstMover = record.FirstMover
+		if len(record.Turns)%2 == 1 {
+			turn.FirstMover = game.Opponent(record.FirstMover)
+		}
+		for i, hand := range battle.Hands {
+			turn.Cards[i] = hand.SelectedCard
+			turn.Powers[i] = hand.SelectedPower
+			turn.Health[i] = hand.Health
+		}
+		turn.Health[game.Opponent(battle.Winner)] -= battle.Damage
+		record.Turns = append(record.Turns, turn)
+	}
+	record.Winner = g.Winner()
+}
+
+//appendGameRecord will add the game record as a new line to the file
+func appendGameRecord(path string, record gameRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+//readGameRecords will read all game records from the file
+func readGameRecords(path string) ([]gameRecord, error) {
+	var records []gameRecord
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record gameRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%v:%v: %v", path, line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+//replayGame will draw the recorded game turn by turn with the rules of the record, moves are validated by the game rules
+func replayGame(record gameRecord) error {
+	if record.Rules != nil {
+		if err := record.Rules.Validate(); err != nil {
+			return fmt.Errorf("incorrect rules: %v", err)
+		}
+		savedRules := gameRules
+		gameRules = *record.Rules
+		defer func() { gameRules = savedRules }()
+	}
+	g := game.New(record.Hands[game.Comp].hand(), record.Hands[game.User].hand(), record.FirstMover)
+	for i, turn := range record.Turns {
+		if g.IsOver() {
+			return fmt.Errorf("turn %v is recorded after the end of the game", i+1)
+		}
+		drawTable(visibleHands(g.Hand(game.Comp), g.Hand(game.User)))
+		if g.ToMove() == game.User {
+			fmt.Fprintln(screen, "USER TURN")
+		} else {
+			fmt.Fprintln(screen, "COMP TURN")
+		}
+		for j := 0; j < 2; j++ {
+			player := g.ToMove()
+			if err := g.Apply(game.Move{Player: player, Card: turn.Cards[player], Power: turn.Powers[player]}); err != nil {
+				return fmt.Errorf("turn %v: %v", i+1, err)
+			}
+			if j == 0 {
+				drawTable(visibleHands(g.Hand(game.Comp), g.Hand(game.User)))
+				pause("Press 'Enter' for the next move...")
+			}
+		}
+		battle := g.LastBattle()
+		drawTable(visibleHands(battle.Hands[game.Comp], battle.Hands[game.User]))
+		pause("Press 'Enter' for the turn results...")
+		drawBattle(battle.Hands[game.Comp], battle.Hands[game.User])
+		for player, health := range turn.Health {
+			if g.Hand(player).Health != health {
+				return fmt.Errorf("turn %v: recorded health %v doesn't match the game health %v", i+1, turn.Health, [2]int{g.Hand(game.Comp).Health, g.Hand(game.User).Health})
+			}
+		}
+		pause("Press 'Enter' for the next turn...")
+	}
+	if !g.IsOver() {
+		return fmt.Errorf("game is not finished after %v turns", len(record.Turns))
+	}
+	drawResult(g)
+	return nil
+}
+
+//runReplay will replay games from the JSON Lines file
+func runReplay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	gameNumber := flags.Int("game", 0, "number of the game in the file starting from 1, 0 replays all games")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: kaart replay [-game N] <file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	records, err := readGameRecords(flags.Arg(0))
+	if err != nil {
+		Logger.Fatal(err)
+	}
+	if *gameNumber < 0 || *gameNumber > len(records) {
+		Logger.Fatalf("Incorrect game number %v. Game number range is 1 .. %v", *gameNumber, len(records))
+	}
+	if *gameNumber > 0 {
+		records = records[*gameNumber-1 : *gameNumber]
+	}
+	for i, record := range records {
+		if err := replayGame(record); err != nil {
+			Logger.Fatal(err)
+		}
+		fmt.Printf("\nSeed: %v, %v vs %v\n", record.Seed, record.Players[game.Comp], record.Players[game.User])
+		if i < len(records)-1 {
+			pause("Press 'Enter' for the next game...")
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//replayOutput will replay the record and return the drawn tables, pauses read the empty input
+func replayOutput(t *testing.T, record gameRecord) string {
+	t.Helper()
+	savedScreen, savedStdin := screen, os.Stdin
+	defer func() { screen, os.Stdin = savedScreen, savedStdin }()
+	var output bytes.Buffer
+	screen = &output
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	os.Stdin = stdin
+	if err := replayGame(record); err != nil {
+		t.Fatal(err)
+	}
+	return output.String()
+}
+
+//recordedGame will play the seeded game between the greedy and random players and return its record
+func recordedGame(t *testing.T, seed int64) (gameRecord, *game.Game) {
+	t.Helper()
+	playerTypes, names := [2]string{playerGreedy, playerRandom}, [2]string{"alice", ""}
+	g, players, err := newSeededGame(seed, playerTypes, names, dealRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := newGameRecord(seed, playerTypes, names, g)
+	if err := playHeadless(g, players); err != nil {
+		t.Fatal(err)
+	}
+	record.finish(g)
+	return record, g
+}
+
+func TestGameRecordRoundTrip(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	tests := []struct {
+		name         string
+		hiddenPower  bool
+		simultaneous bool
+	}{
+		{"default rules", false, false},
+		{"hidden power", true, false},
+		{"simultaneous moves", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "games.jsonl")
+			var records []gameRecord
+			var games []*game.Game
+			for seed := int64(1); seed <= 3; seed++ {
+				gameRules = game.DefaultRules
+				gameRules.HiddenPower, gameRules.Simultaneous = tt.hiddenPower, tt.simultaneous
+				record, g := recordedGame(t, seed)
+				if err := appendGameRecord(path, record); err != nil {
+					t.Fatal(err)
+				}
+				records = append(records, record)
+				games = append(games, g)
+			}
+
+			readRecords, err := readGameRecords(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(readRecords, records) {
+				t.Fatalf("read records %+v are different from the written ones %+v", readRecords, records)
+			}
+			//Replay uses the rules of the record instead of the current ones
+			gameRules = game.DefaultRules
+			for i, record := range readRecords {
+				if record.Rules == nil || record.Rules.HiddenPower != tt.hiddenPower || record.Rules.Simultaneous != tt.simultaneous {
+					t.Fatalf("game %v: rules %+v are not recorded", i+1, record.Rules)
+				}
+				replayOutput(t, record)
+				if gameRules != game.DefaultRules {
+					t.Errorf("game %v: rules %+v are not restored after the replay", i+1, gameRules)
+				}
+				lastTurn := record.Turns[len(record.Turns)-1]
+				for player, health := range lastTurn.Health {
+					if health != games[i].Hand(player).Health {
+						t.Errorf("game %v: replayed health %v, played health %v", i+1, lastTurn.Health, [2]int{games[i].Hand(game.Comp).Health, games[i].Hand(game.User).Health})
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReplayRules(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	gameRules = game.DefaultRules
+	gameRules.HiddenPower = true
+	record, _ := recordedGame(t, 1)
+	hiddenRules := gameRules
+	//Old records without the rules are replayed with the current rules
+	oldRecord := record
+	oldRecord.Rules = nil
+	hiddenOutput := replayOutput(t, oldRecord)
+
+	gameRules = game.DefaultRules
+	if output := replayOutput(t, record); output != hiddenOutput {
+		t.Error("record with the hidden power is replayed with the current rules")
+	}
+	if output := replayOutput(t, oldRecord); output == hiddenOutput {
+		t.Error("record without the rules is replayed with the rules of the other game")
+	}
+
+	hiddenRules.HandSize = 0
+	record.Rules = &hiddenRules
+	if err := replayGame(record); err == nil {
+		t.Error("record with the incorrect rules is replayed")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//gameRules are the rules for all games, loaded from the rules file at startup
+var gameRules = game.DefaultRules
+
+//rulesFile is the content of the rules file, all fields are optional and default to the built-in values
+type rulesFile struct {
+	game.Rules
+	Cards [][]CardData `json:"cards"` //card database tiers for the deck dealer
+}
+
+//rulesFlags are the command line flags for the game rules
+type rulesFlags struct {
+	path         string //rules file
+	handSize     int    //hand size overriding the rules file, 0 to keep the rules file value
+	hidden       bool   //hidden power mode, it is enabled if either the rules file or the flag enables it
+	simultaneous bool   //simultaneous moves mode, it is enabled if either the rules file or the flag enables it
+}
+
+//addRulesFlags will add flags for the rules file and rule overrides to the flag set
+func addRulesFlags(flags *flag.FlagSet) *rulesFlags {
+	rules := &rulesFlags{}
+	flags.StringVar(&rules.path, "rules", "", "JSON file with the game rules and the card database, built-in rules are used if empty")
+	flags.IntVar(&rules.handSize, "hand-size", 0, "number of cards in every hand, overrides the rules file if not 0")
+	flags.BoolVar(&rules.hidden, "hidden", false, "hide the power of the first mover until the battle, bots get only the information visible to the human")
+	flags.BoolVar(&rules.simultaneous, "simultaneous", false, "both players commit card and power without seeing the opponent move, moves are revealed together in the battle")
+	return rules
+}
+
+//load will load the rules file and apply overrides from the command line
+func (rules *rulesFlags) load() error {
+	if err := loadRules(rules.path); err != nil {
+		return err
+	}
+	if rules.hidden {
+		gameRules.HiddenPower = true
+	}
+	if rules.simultaneous {
+		gameRules.Simultaneous = true
+	}
+	if rules.handSize == 0 {
+		return nil
+	}
+	newRules := gameRules
+	newRules.HandSize = rules.handSize
+	if err := newRules.Validate(); err != nil {
+		return err
+	}
+	gameRules = newRules
+	return nil
+}
+
+//validateCards will check that every tier has cards with the value and damage within the rules limits
+func validateCards(cards [][]CardData) error {
+	if len(cards) == 0 {
+		return fmt.Errorf("cards should have at least one tier")
+	}
+	for i, tier := range cards {
+		if len(tier) == 0 {
+			return fmt.Errorf("cards tier %v is empty", i+1)
+		}
+		for j, v := range tier {
+			if v.Power < 1 || v.Power > game.MaxRulesValue {
+				return fmt.Errorf("card %v in tier %v has value %v, should be in range 1 .. %v", j+1, i+1, v.Power, game.MaxRulesValue)
+			}
+			if v.Damage < 0 || v.Damage > game.MaxRulesValue {
+				return fmt.Errorf("card %v in tier %v has damage %v, should be in range 0 .. %v", j+1, i+1, v.Damage, game.MaxRulesValue)
+			}
+		}
+	}
+	return nil
+}
+
+//loadRules will read rules and the card database from the file and replace built-in values
+func loadRules(path string) error {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rules := rulesFile{Rules: game.DefaultRules, Cards: CardDB}
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	if err := rules.Rules.Validate(); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	if err := validateCards(rules.Cards); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	gameRules = rules.Rules
+	CardDB = rules.Cards
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+func TestValidateCards(t *testing.T) {
+	tests := []struct {
+		name    string
+		cards   [][]CardData
+		wantErr bool
+	}{
+		{"built-in cards", CardDB, false},
+		{"largest card", [][]CardData{{{Power: game.MaxRulesValue, Damage: game.MaxRulesValue}}}, false},
+		{"no tiers", nil, true},
+		{"empty tier", [][]CardData{{{Power: 1, Damage: 1}}, {}}, true},
+		{"zero value", [][]CardData{{{Power: 0, Damage: 1}}}, true},
+		{"too big value", [][]CardData{{{Power: game.MaxRulesValue + 1, Damage: 1}}}, true},
+		{"negative damage", [][]CardData{{{Power: 1, Damage: -1}}}, true},
+		{"too big damage", [][]CardData{{{Power: 1, Damage: game.MaxRulesValue + 1}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateCards(tt.cards); (err != nil) != tt.wantErr {
+				t.Errorf("validateCards error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//remotePlayerName is the player type in the records of the network games
+const remotePlayerName string = "remote"
+
+//forfeitError is the error of the player who didn't move in time, the opponent wins the game
+type forfeitError struct {
+	player int
+}
+
+//Error will describe the forfeit
+func (e *forfeitError) Error() string {
+	return fmt.Sprintf("%v player forfeits, no move in time", seatNames[e.player])
+}
+
+//playerError will add the player to the connection error, timeout of the player is the forfeit
+func playerError(player int, err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &forfeitError{player: player}
+	}
+	return fmt.Errorf("%v player: %v", seatNames[player], err)
+}
+
+//serveTurn will ask the player to move for the next move, incorrect moves are rejected and the player is asked again
+func serveTurn(g *game.Game, conns [2]*netConn) error {
+	player := g.ToMove()
+	for i, conn := range conns {
+		if err := conn.send(netMessage{Type: msgState, Seat: i, Hands: clientHands(g, i), ToMove: player}); err != nil {
+			return playerError(i, err)
+		}
+	}
+	for {
+		message, err := conns[player].receive(msgMove)
+		if err != nil {
+			return playerError(player, err)
+		}
+		err = g.Apply(game.Move{Player: player, Card: message.Card, Power: message.Power})
+		if err == nil {
+			return nil
+		}
+		//Server runs the rules, so the player should send another move
+		if err := conns[player].send(netMessage{Type: msgError, Text: err.Error()}); err != nil {
+			return playerError(player, err)
+		}
+		if err := conns[player].send(netMessage{Type: msgState, Seat: player, Hands: clientHands(g, player), ToMove: player}); err != nil {
+			return playerError(player, err)
+		}
+	}
+}
+
+//serveSimultaneousTurn will collect commitments from both players, then moves are revealed, verified and applied.
+//Nonces of the players are returned, so every player can verify the opponent commitment
+func serveSimultaneousTurn(g *game.Game, conns [2]*netConn) ([2][]byte, error) {
+	var commitments [2]game.Commitment
+	var nonces [2][]byte
+	var moves [2]game.Move
+	for i, conn := range conns {
+		if err := conn.send(netMessage{Type: msgCommit, Seat: i, Hands: clientHands(g, i), ToMove: i}); err != nil {
+			return nonces, playerError(i, err)
+		}
+	}
+	for i, conn := range conns {
+		message, err := conn.receive(msgCommit)
+		if err == nil {
+			commitments[i], err = decodeCommitment(message.Commitment)
+		}
+		if err != nil {
+			return nonces, playerError(i, err)
+		}
+	}
+	//Moves are revealed only after both players committed, so nobody can change the move after seeing the opponent move
+	for i, conn := range conns {
+		if err := conn.send(netMessage{Type: msgReveal, Seat: i, Commitment: encodeCommitment(commitments[game.Opponent(i)])}); err != nil {
+			return nonces, playerError(i, err)
+		}
+	}
+	for i, conn := range conns {
+		message, err := conn.receive(msgReveal)
+		if err == nil {
+			moves[i] = game.Move{Player: i, Card: message.Card, Power: message.Power}
+			nonces[i] = message.Nonce
+			err = commitments[i].Verify(moves[i], nonces[i])
+		}
+		if err != nil {
+			return nonces, playerError(i, err)
+		}
+	}
+	for _, player := range [2]int{g.ToMove(), game.Opponent(g.ToMove())} {
+		if err := g.Apply(moves[player]); err != nil {
+			return nonces, fmt.Errorf("%v player revealed incorrect move: %v", seatNames[player], err)
+		}
+	}
+	return nonces, nil
+}
+
+//serveGame will play the game between two connected players, the server runs the rules and sends every player only the visible state
+func serveGame(g *game.Game, conns [2]*netConn) error {
+	for i, conn := range conns {
+		rules := gameRules
+		if err := conn.send(netMessage{Type: msgWelcome, Seat: i, Rules: &rules}); err != nil {
+			return playerError(i, err)
+		}
+	}
+	for !g.IsOver() {
+		var nonces [2][]byte
+		var err error
+		turn := g.Turn()
+		if gameRules.Simultaneous {
+			nonces, err = serveSimultaneousTurn(g, conns)
+		} else {
+			err = serveTurn(g, conns)
+		}
+		if err != nil {
+			return err
+		}
+		if g.Turn() == turn {
+			continue
+		}
+		battle := g.LastBattle()
+		for i, conn := range conns {
+			message := netMessage{Type: msgBattle, Seat: i, Hands: &battle.Hands, Nonce: nonces[game.Opponent(i)]}
+			if err := conn.send(message); err != nil {
+				return playerError(i, err)
+			}
+		}
+	}
+	hands := [2]game.Hand{g.Hand(game.Comp), g.Hand(game.User)}
+	for i, conn := range conns {
+		if err := conn.send(netMessage{Type: msgResult, Seat: i, Hands: &hands, Winner: g.Winner()}); err != nil {
+			return playerError(i, err)
+		}
+	}
+	return nil
+}
+
+//hostGame will deal the new game for the connected players and play it, connections are closed at the end
+func hostGame(seed int64, conns [2]*netConn, rules dealRules, recordPath string, recordMutex *sync.Mutex) {
+	defer func() {
+		for _, conn := range conns {
+			conn.conn.Close()
+		}
+	}()
+	g, _ := newSeededTable(seed, rules)
+	record := newGameRecord(seed, [2]string{remotePlayerName, remotePlayerName}, [2]string{}, g)
+	Logger.Infof("Game %v started: %v vs %v", seed, conns[game.Comp].conn.RemoteAddr(), conns[game.User].conn.RemoteAddr())
+	if err := serveGame(g, conns); err != nil {
+		var forfeit *forfeitError
+		if errors.As(err, &forfeit) {
+			//Forfeited game is not finished, so it is not recorded
+			Logger.Infof("Game %v finished, %v", seed, err)
+			for i, conn := range conns {
+				conn.send(netMessage{Type: msgResult, Seat: i, Hands: clientHands(g, i), Winner: game.Opponent(forfeit.player), Text: err.Error()})
+			}
+			return
+		}
+		Logger.Errorf("Game %v aborted: %v", seed, err)
+		//Tell the players why the game is over, the connection can be already broken
+		for _, conn := range conns {
+			conn.send(netMessage{Type: msgError, Text: fmt.Sprint("game aborted: ", err)})
+		}
+		return
+	}
+	Logger.Infof("Game %v finished, winner %v", seed, g.Winner())
+	if recordPath == "" {
+		return
+	}
+	record.finish(g)
+	recordMutex.Lock()
+	defer recordMutex.Unlock()
+	if err := appendGameRecord(recordPath, record); err != nil {
+		Logger.Error(err)
+	}
+}
+
+//runServe will host games over TCP, every two connected players are matched for the new game
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	address := flags.String("addr", defaultAddress, "TCP address to listen on, use :port to accept players from other hosts")
+	gamesNum := flags.Int("games", 0, "number of games to host, 0 to host games until stopped")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed of the first game, every next game uses seed+1")
+	recordPath := flags.String("record", "games.jsonl", "JSON Lines file to append records of all games to, empty to disable")
+	moveTimeout := flags.Duration("move-timeout", 5*time.Minute, "maximum time to wait for the move of the player, the player forfeits the game after it, 0 to wait forever")
+	rules := addDealFlags(flags)
+	gameRulesFlags := addRulesFlags(flags)
+	flags.Parse(args)
+	if err := gameRulesFlags.load(); err != nil {
+		Logger.Fatal(err)
+	}
+	if err := rules.validate(); err != nil {
+		Logger.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", *address)
+	if err != nil {
+		Logger.Fatal(err)
+	}
+	defer listener.Close()
+	Logger.Info("Listening on", listener.Addr())
+
+	var games sync.WaitGroup
+	var recordMutex sync.Mutex
+	for i := 0; *gamesNum == 0 || i < *gamesNum; i++ {
+		var conns [2]*netConn
+		for j := range conns {
+			conn, err := listener.Accept()
+			if err != nil {
+				Logger.Error(err)
+				os.Exit(1)
+			}
+			Logger.Infof("%v player connected from %v", seatNames[j], conn.RemoteAddr())
+			conns[j] = newNetConn(conn, *moveTimeout)
+		}
+		games.Add(1)
+		go func(gameSeed int64, conns [2]*netConn) {
+			defer games.Done()
+			hostGame(gameSeed, conns, *rules, *recordPath, &recordMutex)
+		}(*seed+int64(i), conns)
+	}
+	games.Wait()
+}
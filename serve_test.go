@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//testNetHand will create the hand for the network tests
+func testNetHand() game.Hand {
+	return game.Hand{Health: 10, Power: 5, SelectedCard: -1, Cards: []game.Card{{Value: 2, Damage: 3, Playable: true}, {Value: 3, Damage: 2, Playable: true}}}
+}
+
+func TestClientHandsHidePower(t *testing.T) {
+	tests := []struct {
+		name      string
+		hidden    bool
+		wantPower int
+	}{
+		{"default mode", false, 3},
+		{"hidden power mode", true, game.HiddenPower},
+	}
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameRules.HiddenPower = tt.hidden
+			g := game.New(testNetHand(), testNetHand(), game.Comp)
+			if err := g.Apply(game.Move{Player: game.Comp, Card: 1, Power: 3}); err != nil {
+				t.Fatal(err)
+			}
+			userHands := clientHands(g, game.User)
+			if userHands[game.Comp].SelectedCard != 1 || userHands[game.Comp].SelectedPower != tt.wantPower {
+				t.Errorf("user sees the comp selection %v with power %v, want card 1 with power %v", userHands[game.Comp].SelectedCard, userHands[game.Comp].SelectedPower, tt.wantPower)
+			}
+			compHands := clientHands(g, game.Comp)
+			if compHands[game.Comp].SelectedPower != 3 {
+				t.Errorf("comp sees own power %v, want 3", compHands[game.Comp].SelectedPower)
+			}
+		})
+	}
+}
+
+func TestServeGameForfeit(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	gameRules = game.DefaultRules
+	for _, firstMover := range []int{game.Comp, game.User} {
+		var conns [2]*netConn
+		for i := range conns {
+			server, client := net.Pipe()
+			defer server.Close()
+			defer client.Close()
+			conns[i] = newNetConn(server, 50*time.Millisecond)
+			//Clients read all messages, but never move
+			go func(client net.Conn) {
+				decoder := json.NewDecoder(client)
+				for {
+					var message netMessage
+					if err := decoder.Decode(&message); err != nil {
+						return
+					}
+				}
+			}(client)
+		}
+		g := game.New(testNetHand(), testNetHand(), firstMover)
+		done := make(chan error, 1)
+		go func() { done <- serveGame(g, conns) }()
+		select {
+		case err := <-done:
+			var forfeit *forfeitError
+			if !errors.As(err, &forfeit) {
+				t.Fatalf("first mover %v: serveGame error = %v, want the forfeit", firstMover, err)
+			}
+			if forfeit.player != firstMover {
+				t.Errorf("forfeit of player %v, want the first mover %v", forfeit.player, firstMover)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("first mover %v: stalled player didn't forfeit", firstMover)
+		}
+	}
+}
+
+func TestPlayerError(t *testing.T) {
+	var forfeit *forfeitError
+	if err := playerError(game.User, errors.New("broken pipe")); errors.As(err, &forfeit) {
+		t.Errorf("connection error %v is the forfeit", err)
+	}
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+	conn := newNetConn(server, time.Millisecond)
+	_, err := conn.receive(msgMove)
+	if err := playerError(game.User, err); !errors.As(err, &forfeit) || forfeit.player != game.User {
+		t.Errorf("timeout error %v is not the forfeit of the user", err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//committedMove is the move hidden behind the commitment until both players committed their moves
+type committedMove struct {
+	commitment game.Commitment
+	move       game.Move
+	nonce      []byte
+}
+
+//commitMove will ask the player for the move and commit to it, the game is not changed
+func commitMove(g *game.Game, players [2]Player, player int) (committedMove, error) {
+	var committed committedMove
+	var err error
+	committed.move = game.Move{Player: player}
+	committed.move.Card, committed.move.Power = players[player].ChooseMove(playerState(g, player))
+	if committed.nonce, err = game.NewNonce(); err != nil {
+		return committed, err
+	}
+	committed.commitment = game.Commit(committed.move, committed.nonce)
+	return committed, nil
+}
+
+//playSimultaneousTurn will let both players commit their moves without seeing the opponent move, then both moves are revealed,
+//verified and the battle is resolved. beforeMove is called before every player selects the move, it can be nil
+func playSimultaneousTurn(g *game.Game, players [2]Player, beforeMove func(player int)) error {
+	var committed [2]committedMove
+	//Moves are applied in the first mover order, but nothing is applied until both players committed
+	order := [2]int{g.ToMove(), game.Opponent(g.ToMove())}
+	for _, player := range order {
+		if beforeMove != nil {
+			beforeMove(player)
+		}
+		move, err := commitMove(g, players, player)
+		if err != nil {
+			return err
+		}
+		committed[player] = move
+	}
+	for _, player := range order {
+		if err := committed[player].commitment.Verify(committed[player].move, committed[player].nonce); err != nil {
+			return err
+		}
+		if err := g.Apply(committed[player].move); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//hideSelection will return a copy of the hand without the selected card and power
+func hideSelection(hand game.Hand) game.Hand {
+	newHand := hand.Copy()
+	newHand.SelectedCard = -1
+	newHand.SelectedPower = 0
+	return newHand
+}
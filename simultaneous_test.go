@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//fixedPlayer always plays the same move and remembers the states it got
+type fixedPlayer struct {
+	card   int
+	power  int
+	states *[]State
+}
+
+//ChooseMove will return the fixed move
+func (p fixedPlayer) ChooseMove(state State) (int, int) {
+	*p.states = append(*p.states, state)
+	return p.card, p.power
+}
+
+func TestPlaySimultaneousTurn(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	gameRules = game.DefaultRules
+	gameRules.Simultaneous = true
+	tests := []struct {
+		name    string
+		moves   [2][2]int //card and power of the comp and the user
+		wantErr bool
+	}{
+		{"legal moves", [2][2]int{{0, 2}, {1, 3}}, false},
+		{"illegal first mover card", [2][2]int{{2, 0}, {1, 3}}, true},
+		{"illegal second mover power", [2][2]int{{0, 2}, {1, 7}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hand := game.Hand{Health: 10, Power: 6, SelectedCard: -1, Cards: []game.Card{{Value: 2, Damage: 3, Playable: true}, {Value: 3, Damage: 2, Playable: true}}}
+			g := game.New(hand, hand.Copy(), game.Comp)
+			var states [2][]State
+			var players [2]Player
+			for i := range players {
+				players[i] = fixedPlayer{card: tt.moves[i][0], power: tt.moves[i][1], states: &states[i]}
+			}
+			var movers []int
+			err := playSimultaneousTurn(g, players, func(player int) { movers = append(movers, player) })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(movers) != 2 || movers[0] != game.Comp || movers[1] != game.User {
+				t.Errorf("players moved in the order %v", movers)
+			}
+			//Both players commit before any move is applied, so neither sees the opponent selection
+			for i, v := range states {
+				if len(v) != 1 {
+					t.Fatalf("player %v is asked %v times", i, len(v))
+				}
+				if v[0].Opponent.SelectedCard != -1 || v[0].Hand.SelectedCard != -1 {
+					t.Errorf("player %v sees the selection: own %v, opponent %v", i, v[0].Hand.SelectedCard, v[0].Opponent.SelectedCard)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+			if g.Turn() != 1 || len(g.Battles()) != 1 {
+				t.Fatalf("turn %v with %v battles after the simultaneous turn", g.Turn(), len(g.Battles()))
+			}
+			battle := g.LastBattle()
+			for i, v := range battle.Hands {
+				if v.SelectedCard != tt.moves[i][0] || v.SelectedPower != tt.moves[i][1] {
+					t.Errorf("player %v played %v with power %v, want %v", i, v.SelectedCard, v.SelectedPower, tt.moves[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//Solver modes, the current turn is always solved as the game, where the second mover sees the part of the first mover move allowed by the rules,
+//modes differ in the value of the next turns
+const (
+	solverExpectimax  int = iota //opponent selects every move with the same probability, comp plays the best response to it in every information set
+	solverMinimax                //opponent selects the worst move for the comp after seeing the comp move, so the value is the pessimistic bound
+	solverEquilibrium            //every turn is solved like the current turn, so the value is the game-theoretic value of the game
+)
+
+//solverModeNames are the names of the solver modes for the command line
+var solverModeNames = []string{"expectimax", "minimax", "equilibrium"}
+
+//Solver parameters
+var (
+	solverMode             int = solverExpectimax //how to evaluate opponent moves after the current turn
+	solverIterations       int = 1000             //number of regret matching iterations to find mixed strategy for the current turn
+	solverNodeIterations   int = 300              //number of regret matching iterations to find the value of every next turn in the equilibrium mode
+	solverMaxHandSize      int = 5                //largest hand size the exhaustive search can handle in reasonable time
+	solverEquilibriumHands int = 4                //largest hand size the equilibrium mode can handle in reasonable time
+)
+
+//solverModeFlag is the command line flag for the solver mode
+type solverModeFlag int
+
+//Game results in terms of the comp payoff
+const (
+	solverWin  float32 = 1
+	solverDraw float32 = 0.5
+	solverLoss float32 = 0
+)
+
+//solverState is a compact game state, the first mover is stored only for the alternating turns, because the second mover sees the first mover move
+type solverState struct {
+	compCards  uint8 //bit mask of the playable comp cards
+	userCards  uint8 //bit mask of the playable user cards
+	compPower  int8
+	userPower  int8
+	compHealth int8
+	userHealth int8
+	firstMover int8 //game.Comp or game.User in terms of the solver, always game.Comp for the simultaneous turns
+}
+
+//solverMove is a card and power selected by the player in the solver state
+type solverMove struct {
+	card  int
+	power int
+}
+
+//Solver will calculate game value for all reachable states of two hands
+type solver struct {
+	seat      int //index of the comp in the game
+	compCards []game.Card
+	userCards []game.Card
+	values    map[solverState]float32
+}
+
+//String will return the name of the solver mode
+func (mode *solverModeFlag) String() string {
+	return solverModeNames[*mode]
+}
+
+//Set will parse the name of the solver mode
+func (mode *solverModeFlag) Set(value string) error {
+	for i, v := range solverModeNames {
+		if v == value {
+			*mode = solverModeFlag(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown solver mode %q, available modes are %v", value, solverModeNames)
+}
+
+func newSolver(compHand game.Hand, userHand game.Hand, seat int) *solver {
+	return &solver{
+		seat:      seat,
+		compCards: compHand.Cards,
+		userCards: userHand.Cards,
+		values:    make(map[solverState]float32),
+	}
+}
+
+func handCardsMask(hand game.Hand) uint8 {
+	var mask uint8
+	for i, v := range hand.Cards {
+		if v.Playable {
+			mask |= 1 << uint(i)
+		}
+	}
+	return mask
+}
+
+//newSolverState will create the state of the comp turn, user moves first only if the user already selected the card
+func newSolverState(compHand game.Hand, userHand game.Hand) solverState {
+	state := solverState{
+		compCards:  handCardsMask(compHand),
+		userCards:  handCardsMask(userHand),
+		compPower:  int8(compHand.Power),
+		userPower:  int8(userHand.Power),
+		compHealth: int8(compHand.Health),
+		userHealth: int8(userHand.Health),
+		firstMover: int8(game.Comp),
+	}
+	if userHand.SelectedCard != -1 && !gameRules.Simultaneous {
+		state.firstMover = int8(game.User)
+	}
+	return state
+}
+
+//isOver will return true if one of the players has no health or all cards are played
+func (state solverState) isOver() bool {
+	return state.compHealth < 1 || state.userHealth < 1 || state.compCards == 0 || state.userCards == 0
+}
+
+//result will return the comp payoff for the finished game
+func (state solverState) result() float32 {
+	if state.compHealth == state.userHealth {
+		return solverDraw
+	}
+	if state.compHealth > state.userHealth {
+		return solverWin
+	}
+	return solverLoss
+}
+
+//solverMoves will return all moves for the cards from the mask. Unused power is lost, so the last card always takes all power
+func solverMoves(cards uint8, power int8) []solverMove {
+	var moves []solverMove
+	var cardsNum int
+	for i := 0; i < 8; i++ {
+		if cards&(1<<uint(i)) != 0 {
+			cardsNum++
+		}
+	}
+	for i := 0; i < 8; i++ {
+		if cards&(1<<uint(i)) == 0 {
+			continue
+		}
+		if cardsNum == 1 {
+			moves = append(moves, solverMove{card: i, power: int(power)})
+			continue
+		}
+		for p := 0; p <= int(power); p++ {
+			moves = append(moves, solverMove{card: i, power: p})
+		}
+	}
+	return moves
+}
+
+//next will return the state after both players played their moves
+func (s *solver) next(state solverState, compMove solverMove, userMove solverMove) solverState {
+	compAttack := game.Attack(s.compCards[compMove.card], compMove.power)
+	userAttack := game.Attack(s.userCards[userMove.card], userMove.power)
+	if game.WinsBattle(s.seat, compAttack, userAttack) {
+		state.userHealth -= int8(s.compCards[compMove.card].Damage)
+	} else {
+		state.compHealth -= int8(s.userCards[userMove.card].Damage)
+	}
+	//Negative health is the same as zero health for the game result
+	if state.userHealth < 0 {
+		state.userHealth = 0
+	}
+	if state.compHealth < 0 {
+		state.compHealth = 0
+	}
+	state.compCards &^= 1 << uint(compMove.card)
+	state.userCards &^= 1 << uint(userMove.card)
+	state.compPower -= int8(compMove.power)
+	state.userPower -= int8(userMove.power)
+	//Players take turns to select the card first
+	if !gameRules.Simultaneous {
+		state.firstMover = int8(game.Opponent(int(state.firstMover)))
+	}
+	return state
+}
+
+//value will return the comp payoff for the state according to solverMode, in the equilibrium mode the turn is solved like the current turn
+func (s *solver) value(state solverState) float32 {
+	if state.isOver() {
+		return state.result()
+	}
+	if v, ok := s.values[state]; ok {
+		return v
+	}
+	compMoves := solverMoves(state.compCards, state.compPower)
+	userMoves := solverMoves(state.userCards, state.userPower)
+	matrix := s.payoffMatrix(state, compMoves, userMoves)
+	var value float32
+	switch solverMode {
+	case solverEquilibrium:
+		_, value = turnStrategy(matrix, compMoves, userMoves, int(state.firstMover), solverNodeIterations)
+	case solverMinimax:
+		value = solverLoss
+		for i := range compMoves {
+			moveValue := solverWin
+			for _, v := range matrix[i] {
+				if v < moveValue {
+					moveValue = v
+				}
+			}
+			if moveValue > value {
+				value = moveValue
+			}
+		}
+	default:
+		//Comp sees the observation of the user move, if the user moves first, so the best comp move is selected for every information set of the user
+		userGroups := [][]int{nil}
+		for j := range userMoves {
+			userGroups[0] = append(userGroups[0], j)
+		}
+		if int(state.firstMover) == game.User {
+			userGroups = observationGroups(userMoves)
+		}
+		for _, group := range userGroups {
+			groupValue := solverLoss
+			for i := range compMoves {
+				var moveValue float32
+				for _, j := range group {
+					moveValue += matrix[i][j]
+				}
+				if moveValue > groupValue {
+					groupValue = moveValue
+				}
+			}
+			value += groupValue / float32(len(userMoves))
+		}
+	}
+	s.values[state] = value
+	return value
+}
+
+//payoffMatrix will return comp payoff for every pair of the comp and user moves in the current turn
+func (s *solver) payoffMatrix(state solverState, compMoves []solverMove, userMoves []solverMove) [][]float32 {
+	matrix := make([][]float32, len(compMoves))
+	for i, compMove := range compMoves {
+		matrix[i] = make([]float32, len(userMoves))
+		for j, userMove := range userMoves {
+			matrix[i][j] = s.value(s.next(state, compMove, userMove))
+		}
+	}
+	return matrix
+}
+
+//solveMatrixGame will find mixed strategy of the row player in zero-sum matrix game with regret matching
+func solveMatrixGame(matrix [][]float32, iterations int) []float32 {
+	rowsNum := len(matrix)
+	colsNum := len(matrix[0])
+	rowRegrets := make([]float32, rowsNum)
+	colRegrets := make([]float32, colsNum)
+	rowStrategySum := make([]float32, rowsNum)
+	rowStrategy := make([]float32, rowsNum)
+	colStrategy := make([]float32, colsNum)
+	rowPayoffs := make([]float32, rowsNum)
+	colPayoffs := make([]float32, colsNum)
+	for iteration := 0; iteration < iterations; iteration++ {
+		regretsToStrategy(rowRegrets, rowStrategy)
+		regretsToStrategy(colRegrets, colStrategy)
+		//Expected payoff of every pure strategy against the current strategy of the other player
+		var expectedPayoff float32
+		for i := range rowPayoffs {
+			rowPayoffs[i] = 0
+			for j := range colPayoffs {
+				rowPayoffs[i] += matrix[i][j] * colStrategy[j]
+			}
+			expectedPayoff += rowPayoffs[i] * rowStrategy[i]
+		}
+		for j := range colPayoffs {
+			colPayoffs[j] = 0
+			for i := range rowPayoffs {
+				colPayoffs[j] += matrix[i][j] * rowStrategy[i]
+			}
+		}
+		for i := range rowRegrets {
+			rowRegrets[i] += rowPayoffs[i] - expectedPayoff
+			rowStrategySum[i] += rowStrategy[i]
+		}
+		//Column player minimizes the comp payoff
+		for j := range colRegrets {
+			colRegrets[j] += expectedPayoff - colPayoffs[j]
+		}
+	}
+	//Average strategy converges to the equilibrium
+	for i := range rowStrategySum {
+		rowStrategySum[i] /= float32(iterations)
+	}
+	return rowStrategySum
+}
+
+//matrixStrategy will return the comp mixed strategy and the comp payoff guaranteed by it against the best response of the user,
+//the game with the single move of one player has the exact pure solution
+func matrixStrategy(matrix [][]float32, iterations int) ([]float32, float32) {
+	strategy := make([]float32, len(matrix))
+	switch {
+	case len(matrix) == 1:
+		strategy[0] = 1
+	case len(matrix[0]) == 1:
+		best := 0
+		for i := range matrix {
+			if matrix[i][0] > matrix[best][0] {
+				best = i
+			}
+		}
+		strategy[best] = 1
+	default:
+		strategy = solveMatrixGame(matrix, iterations)
+	}
+	return strategy, matrixGameValue(matrix, strategy)
+}
+
+//observationGroups will split the indexes of the first mover moves into the information sets of the second mover, moves with the same observation can't be told apart
+func observationGroups(moves []solverMove) [][]int {
+	var groups [][]int
+	groupIndexes := make(map[int]int)
+	for i, v := range moves {
+		observation := mctsObservation(v, i)
+		index, ok := groupIndexes[observation]
+		if !ok {
+			index = len(groups)
+			groupIndexes[observation] = index
+			groups = append(groups, nil)
+		}
+		groups[index] = append(groups[index], i)
+	}
+	return groups
+}
+
+//turnStrategy will return the comp strategy and the comp payoff of the turn with the payoff matrix of the comp and user moves.
+//Simultaneous turn is the matrix game. In the alternating turn the second mover plays the best response in every information set,
+//so the first mover selects the best information set and mixes only the moves inside it, which are the powers of the card, if the power is hidden.
+//If the user moves first, the comp strategy is the response to the worst information set, the current turn has the single information set of the selected card
+func turnStrategy(matrix [][]float32, compMoves []solverMove, userMoves []solverMove, firstMover int, iterations int) ([]float32, float32) {
+	if gameRules.Simultaneous {
+		return matrixStrategy(matrix, iterations)
+	}
+	if firstMover == game.Comp {
+		strategy := make([]float32, len(compMoves))
+		bestValue := float32(-1)
+		for _, group := range observationGroups(compMoves) {
+			groupMatrix := make([][]float32, len(group))
+			for i, v := range group {
+				groupMatrix[i] = matrix[v]
+			}
+			groupStrategy, groupValue := matrixStrategy(groupMatrix, iterations)
+			if groupValue > bestValue {
+				bestValue = groupValue
+				for i := range strategy {
+					strategy[i] = 0
+				}
+				for i, v := range group {
+					strategy[v] = groupStrategy[i]
+				}
+			}
+		}
+		return strategy, bestValue
+	}
+	var strategy []float32
+	worstValue := solverWin + 1
+	for _, group := range observationGroups(userMoves) {
+		groupMatrix := make([][]float32, len(compMoves))
+		for i := range compMoves {
+			for _, v := range group {
+				groupMatrix[i] = append(groupMatrix[i], matrix[i][v])
+			}
+		}
+		groupStrategy, groupValue := matrixStrategy(groupMatrix, iterations)
+		if groupValue < worstValue {
+			strategy, worstValue = groupStrategy, groupValue
+		}
+	}
+	return strategy, worstValue
+}
+
+//matrixGameValue will return the comp payoff guaranteed by the mixed strategy against the best response of the user
+func matrixGameValue(matrix [][]float32, strategy []float32) float32 {
+	value := solverWin
+	for j := range matrix[0] {
+		var colPayoff float32
+		for i := range matrix {
+			colPayoff += matrix[i][j] * strategy[i]
+		}
+		if colPayoff < value {
+			value = colPayoff
+		}
+	}
+	return value
+}
+
+//regretsToStrategy will convert positive regrets to probabilities, or uniform strategy if there are no positive regrets
+func regretsToStrategy(regrets []float32, strategy []float32) {
+	var totalRegret float32
+	for _, v := range regrets {
+		if v > 0 {
+			totalRegret += v
+		}
+	}
+	for i, v := range regrets {
+		switch {
+		case totalRegret == 0:
+			strategy[i] = 1 / float32(len(regrets))
+		case v > 0:
+			strategy[i] = v / totalRegret
+		default:
+			strategy[i] = 0
+		}
+	}
+}
+
+//userTurnMoves will return the user moves still possible in the current turn, the user can already have the selected card with the hidden or visible power
+func userTurnMoves(userHand game.Hand, state solverState) []solverMove {
+	switch {
+	case userHand.SelectedCard == -1:
+		return solverMoves(state.userCards, state.userPower)
+	case userHand.SelectedPower == game.HiddenPower:
+		//User selected the card, but the power is hidden, so the user can have any power for this card
+		var userMoves []solverMove
+		for _, v := range solverMoves(state.userCards, state.userPower) {
+			if v.card == userHand.SelectedCard {
+				userMoves = append(userMoves, v)
+			}
+		}
+		return userMoves
+	}
+	//User already moved, so comp can play the best response
+	return []solverMove{{card: userHand.SelectedCard, power: userHand.SelectedPower}}
+}
+
+//GetSolverMove will return card number and power for the next comp move calculated by the exhaustive game tree search, seat is the comp index in the game
+func GetSolverMove(compHand game.Hand, userHand game.Hand, seat int, rnd *rand.Rand) (int, int) {
+	s := newSolver(compHand, userHand, seat)
+	state := newSolverState(compHand, userHand)
+	compMoves := solverMoves(state.compCards, state.compPower)
+	userMoves := userTurnMoves(userHand, state)
+	matrix := s.payoffMatrix(state, compMoves, userMoves)
+	Logger.Debug("solver states =", len(s.values))
+
+	//Sample the move from the mixed strategy, so the user can't exploit the comp, the best response is the pure strategy
+	strategy, _ := turnStrategy(matrix, compMoves, userMoves, int(state.firstMover), solverIterations)
+	Logger.Debug("solver strategy =", strategy)
+	sample := rnd.Float32()
+	for i, v := range strategy {
+		sample -= v
+		if sample < 0 {
+			return compMoves[i].card, compMoves[i].power
+		}
+	}
+	return compMoves[len(compMoves)-1].card, compMoves[len(compMoves)-1].power
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//powerGuessHands are two turns, where comp wins only the turn with the power against the user without the power.
+//Comp wins the first turn with the power and loses the second one without the power, so the only results are draw and loss:
+//comp payoff is 0.5 if comp and user put the power into the different turns and 0 otherwise, like matching pennies
+func powerGuessHands() (game.Hand, game.Hand) {
+	compHand := game.Hand{Health: 2, Power: 1, SelectedCard: -1, Cards: []game.Card{{Value: 1, Damage: 1, Playable: true}, {Value: 1, Damage: 1, Playable: true}}}
+	userHand := game.Hand{Health: 2, Power: 1, SelectedCard: -1, Cards: []game.Card{{Value: 2, Damage: 1, Playable: true}, {Value: 2, Damage: 1, Playable: true}}}
+	return compHand, userHand
+}
+
+//powerGuessRules will return the default rules with the hidden power and simultaneous moves
+func powerGuessRules(hiddenPower bool, simultaneous bool) game.Rules {
+	rules := game.DefaultRules
+	rules.HiddenPower, rules.Simultaneous = hiddenPower, simultaneous
+	return rules
+}
+
+func TestSolverValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  int
+		rules game.Rules
+		want  float32
+	}{
+		//Comp can't tell where the user puts the power, so both turns are equally good
+		{"expectimax", solverExpectimax, powerGuessRules(false, false), 0.25},
+		//User sees the comp power and puts own power into the same turn
+		{"minimax", solverMinimax, powerGuessRules(false, false), 0},
+		//Comp moves first in the first turn and the user sees the comp power
+		{"equilibrium with the visible power", solverEquilibrium, powerGuessRules(false, false), 0},
+		//User sees only the comp card, so both players mix the turns equally
+		{"equilibrium with the hidden power", solverEquilibrium, powerGuessRules(true, false), 0.25},
+		{"equilibrium with the simultaneous moves", solverEquilibrium, powerGuessRules(false, true), 0.25},
+	}
+	savedMode, savedRules := solverMode, gameRules
+	defer func() { solverMode, gameRules = savedMode, savedRules }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solverMode, gameRules = tt.mode, tt.rules
+			compHand, userHand := powerGuessHands()
+			s := newSolver(compHand, userHand, game.Comp)
+			if got := s.value(newSolverState(compHand, userHand)); math.Abs(float64(got-tt.want)) > 0.01 {
+				t.Errorf("value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolverFinishedValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		compHealth int
+		userHealth int
+		want       float32
+	}{
+		{"win", 3, 1, solverWin},
+		{"draw", 2, 2, solverDraw},
+		{"loss", 0, 4, solverLoss},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compHand, userHand := powerGuessHands()
+			compHand.Health, userHand.Health = tt.compHealth, tt.userHealth
+			compHand.Cards[0].Playable, compHand.Cards[1].Playable = false, false
+			s := newSolver(compHand, userHand, game.Comp)
+			if got := s.value(newSolverState(compHand, userHand)); got != tt.want {
+				t.Errorf("value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatrixGameValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix [][]float32
+		want   float32
+	}{
+		{"pure saddle point", [][]float32{{0.5, 1}, {0, 0.2}}, 0.5},
+		{"matching pennies", [][]float32{{1, 0}, {0, 1}}, 0.5},
+		//Comp plays the first row with probability 1/3, expectimax would take 0.5 of the first row and minimax 0
+		{"asymmetric mixed", [][]float32{{0, 1}, {0.5, 0}}, 1.0 / 3},
+		{"single move", [][]float32{{0.7}}, 0.7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matrixGameValue(tt.matrix, solveMatrixGame(tt.matrix, solverIterations)); math.Abs(float64(got-tt.want)) > 0.02 {
+				t.Errorf("value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSolverMoveEquilibrium(t *testing.T) {
+	savedMode, savedRules := solverMode, gameRules
+	defer func() { solverMode, gameRules = savedMode, savedRules }()
+	solverMode = solverEquilibrium
+	tests := []struct {
+		name         string
+		rules        game.Rules
+		userPower    int //power of the user card 0 selected before the comp move, -1 if the user didn't move
+		wantPowers   []int
+		wantPowerMix bool
+	}{
+		//User sees the comp power, so the comp can't gain by mixing
+		{"comp moves first with the visible power", powerGuessRules(false, false), -1, []int{0, 1}, false},
+		{"comp moves first with the hidden power", powerGuessRules(true, false), -1, []int{0, 1}, true},
+		{"simultaneous moves", powerGuessRules(false, true), -1, []int{0, 1}, true},
+		//Comp saves the power for the second turn, where it wins the tie against the user without the power
+		{"best response to the visible power", powerGuessRules(false, false), 1, []int{0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameRules = tt.rules
+			compHand, userHand := powerGuessHands()
+			if tt.userPower >= 0 {
+				userHand.SelectedCard, userHand.SelectedPower = 0, tt.userPower
+			}
+			powers := make(map[int]bool)
+			for seed := int64(0); seed < 20; seed++ {
+				card, power := GetSolverMove(compHand, userHand, game.Comp, rand.New(rand.NewSource(seed)))
+				if card < 0 || card > 1 || power < 0 || power > compHand.Power {
+					t.Fatalf("seed %v: move card %v, power %v is not legal", seed, card, power)
+				}
+				powers[power] = true
+			}
+			for power := range powers {
+				found := false
+				for _, v := range tt.wantPowers {
+					found = found || v == power
+				}
+				if !found {
+					t.Errorf("power %v is played, want one of %v", power, tt.wantPowers)
+				}
+			}
+			if mixed := len(powers) > 1; mixed != tt.wantPowerMix {
+				t.Errorf("powers %v are played, mixed strategy is %v", powers, tt.wantPowerMix)
+			}
+		})
+	}
+}
+
+func TestTurnStrategy(t *testing.T) {
+	savedRules := gameRules
+	defer func() { gameRules = savedRules }()
+	//Comp and user play the card 0 or 1 with the power 0 or 1, comp wins only if the powers are different
+	moves := []solverMove{{0, 0}, {0, 1}, {1, 0}, {1, 1}}
+	matrix := [][]float32{{0, 1, 0, 1}, {1, 0, 1, 0}, {0, 1, 0, 1}, {1, 0, 1, 0}}
+	tests := []struct {
+		name       string
+		rules      game.Rules
+		firstMover int
+		want       float32
+	}{
+		{"simultaneous moves", powerGuessRules(false, true), game.Comp, 0.5},
+		{"user sees the comp power", powerGuessRules(false, false), game.Comp, 0},
+		{"comp sees the user power", powerGuessRules(false, false), game.User, 1},
+		{"user sees only the comp card", powerGuessRules(true, false), game.Comp, 0.5},
+		{"comp sees only the user card", powerGuessRules(true, false), game.User, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gameRules = tt.rules
+			strategy, value := turnStrategy(matrix, moves, moves, tt.firstMover, solverIterations)
+			if math.Abs(float64(value-tt.want)) > 0.02 {
+				t.Errorf("value = %v, want %v", value, tt.want)
+			}
+			var total float32
+			for _, v := range strategy {
+				total += v
+			}
+			if len(strategy) != len(moves) || math.Abs(float64(total-1)) > 0.01 {
+				t.Errorf("strategy %v is not the distribution over %v moves", strategy, len(moves))
+			}
+		})
+	}
+}
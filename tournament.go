@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+//confidenceZ is the z-score for the 95% confidence intervals
+const confidenceZ float64 = 1.96
+
+//timedPlayer measures time spent by the player to choose moves
+type timedPlayer struct {
+	player    Player
+	moves     int
+	totalTime time.Duration
+	maxTime   time.Duration
+}
+
+//ChooseMove will call the wrapped player and record the time
+func (p *timedPlayer) ChooseMove(state State) (int, int) {
+	start := time.Now()
+	cardNumber, cardPower := p.player.ChooseMove(state)
+	elapsed := time.Since(start)
+	p.moves++
+	p.totalTime += elapsed
+	if elapsed > p.maxTime {
+		p.maxTime = elapsed
+	}
+	return cardNumber, cardPower
+}
+
+//tournamentStats is the summary of all games from the first player perspective
+type tournamentStats struct {
+	games       int
+	wins        int
+	draws       int
+	losses      int
+	totalHealth [2]int //remaining health of the first and second player
+}
+
+//playHeadless will play the game until the end without drawing the table
+func playHeadless(g *game.Game, players [2]Player) error {
+	for !g.IsOver() {
+		if gameRules.Simultaneous {
+			if err := playSimultaneousTurn(g, players, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := g.Apply(processTurn(g, players)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//add will count the result of the finished game for the player in the first seat
+func (stats *tournamentStats) add(g *game.Game, firstSeat int) {
+	stats.games++
+	switch g.Winner() {
+	case game.Draw:
+		stats.draws++
+	case firstSeat:
+		stats.wins++
+	default:
+		stats.losses++
+	}
+	stats.totalHealth[0] += remainingHealth(g.Hand(firstSeat))
+	stats.totalHealth[1] += remainingHealth(g.Hand(game.Opponent(firstSeat)))
+}
+
+//wilsonInterval will return 95% confidence interval for the rate of successes in the trials
+func wilsonInterval(successes int, trials int) (float64, float64) {
+	if trials == 0 {
+		return 0, 0
+	}
+	n := float64(trials)
+	p := float64(successes) / n
+	denominator := 1 + confidenceZ*confidenceZ/n
+	center := (p + confidenceZ*confidenceZ/(2*n)) / denominator
+	margin := confidenceZ * math.Sqrt(p*(1-p)/n+confidenceZ*confidenceZ/(4*n*n)) / denominator
+	return center - margin, center + margin
+}
+
+//remainingHealth will return health of the hand, dead player has zero health
+func remainingHealth(hand game.Hand) int {
+	if hand.Health < 0 {
+		return 0
+	}
+	return hand.Health
+}
+
+func printRate(name string, count int, total int) {
+	low, high := wilsonInterval(count, total)
+	fmt.Printf("%-8v %6d  %6.2f%%  [%6.2f%% .. %6.2f%%]\n", name, count, 100*float64(count)/float64(total), 100*low, 100*high)
+}
+
+//runTournament will play number of games between two players and print the statistics
+func runTournament(args []string) {
+	flags := flag.NewFlagSet("tournament", flag.ExitOnError)
+	firstType := flags.String("first", playerGA, playerUsage("first player"))
+	secondType := flags.String("second", playerRandom, playerUsage("second player"))
+	gamesNum := flags.Int("games", 100, "number of games to play")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed of the first game, every next game uses seed+1")
+	alternate := flags.Bool("alternate", true, "swap comp and user seats every other game")
+	csvPath := flags.String("csv", "", "path to the CSV file with results of every game")
+	recordPath := flags.String("record", "", "JSON Lines file to append records of all games to")
+	modelPath := flags.String("model", "", "JSON file with the power models of the players, ga players weight the opponent plans by the model of the opponent type, models are not updated")
+	rules := addDealFlags(flags)
+	addBotFlags(flags)
+	gameRulesFlags := addRulesFlags(flags)
+	flags.Parse(args)
+	if err := gameRulesFlags.load(); err != nil {
+		Logger.Fatal(err)
+	}
+	if err := rules.validate(); err != nil {
+		Logger.Fatal(err)
+	}
+	models, err := loadPowerModels(*modelPath)
+	if err != nil {
+		Logger.Fatal(err)
+	}
+	opponentModels = models
+
+	var timedPlayers [2]*timedPlayer
+	for i, v := range []string{*firstType, *secondType} {
+		if v == playerHuman {
+			Logger.Fatal("human player can't play in the tournament")
+		}
+		timedPlayers[i] = &timedPlayer{}
+	}
+
+	var csvWriter *csv.Writer
+	if *csvPath != "" {
+		csvFile, err := os.Create(*csvPath)
+		if err != nil {
+			Logger.Fatal(err)
+		}
+		defer csvFile.Close()
+		csvWriter = csv.NewWriter(csvFile)
+		defer csvWriter.Flush()
+		csvWriter.Write([]string{"game", "seed", "comp", "user", "first_mover", "comp_health", "user_health", "winner", "comp_move_time_us", "user_move_time_us"})
+	}
+
+	var stats tournamentStats
+	for i := 0; i < *gamesNum; i++ {
+		gameSeed := *seed + int64(i)
+		//First player sits in the comp seat, unless seats are swapped
+		firstSeat := game.Comp
+		if *alternate && i%2 == 1 {
+			firstSeat = game.User
+		}
+		var playerNames [2]string
+		playerNames[firstSeat], playerNames[game.Opponent(firstSeat)] = *firstType, *secondType
+		g, seatPlayers, err := newSeededGame(gameSeed, playerNames, [2]string{}, *rules)
+		if err != nil {
+			Logger.Fatal(err)
+		}
+		var players [2]Player
+		timedPlayers[0].player, timedPlayers[1].player = seatPlayers[firstSeat], seatPlayers[game.Opponent(firstSeat)]
+		players[firstSeat], players[game.Opponent(firstSeat)] = timedPlayers[0], timedPlayers[1]
+		firstMover := g.FirstMover()
+		record := newGameRecord(gameSeed, playerNames, [2]string{}, g)
+		//Remember players timing to calculate time per move in this game
+		var startMoves [2]int
+		var startTime [2]time.Duration
+		for j, v := range timedPlayers {
+			startMoves[j], startTime[j] = v.moves, v.totalTime
+		}
+		if err := playHeadless(g, players); err != nil {
+			Logger.Fatal(err)
+		}
+		if *recordPath != "" {
+			record.finish(g)
+			if err := appendGameRecord(*recordPath, record); err != nil {
+				Logger.Fatal(err)
+			}
+		}
+
+		stats.add(g, firstSeat)
+
+		if csvWriter != nil {
+			winner := "draw"
+			switch g.Winner() {
+			case game.Comp:
+				winner = "comp"
+			case game.User:
+				winner = "user"
+			}
+			var moveTime [2]string
+			for i, v := range timedPlayers {
+				seat := firstSeat
+				if i == 1 {
+					seat = game.Opponent(firstSeat)
+				}
+				moveTime[seat] = "0"
+				if v.moves > startMoves[i] {
+					moveTime[seat] = strconv.FormatInt(((v.totalTime - startTime[i]) / time.Duration(v.moves-startMoves[i])).Microseconds(), 10)
+				}
+			}
+			csvWriter.Write([]string{
+				strconv.Itoa(i),
+				strconv.FormatInt(gameSeed, 10),
+				playerNames[game.Comp],
+				playerNames[game.User],
+				[]string{"comp", "user"}[firstMover],
+				strconv.Itoa(g.Hand(game.Comp).Health),
+				strconv.Itoa(g.Hand(game.User).Health),
+				winner,
+				moveTime[game.Comp],
+				moveTime[game.User],
+			})
+		}
+	}
+
+	fmt.Printf("%v vs %v, %v games, seed %v\n", *firstType, *secondType, stats.games, *seed)
+	fmt.Printf("Results for %v:\n", *firstType)
+	printRate("Wins", stats.wins, stats.games)
+	printRate("Draws", stats.draws, stats.games)
+	printRate("Losses", stats.losses, stats.games)
+	fmt.Println("Average remaining health:")
+	for i, v := range []string{*firstType, *secondType} {
+		fmt.Printf("%-8v %6.2f\n", v, float64(stats.totalHealth[i])/float64(stats.games))
+	}
+	fmt.Println("Time per move:")
+	for i, v := range []string{*firstType, *secondType} {
+		var averageTime time.Duration
+		if timedPlayers[i].moves > 0 {
+			averageTime = timedPlayers[i].totalTime / time.Duration(timedPlayers[i].moves)
+		}
+		fmt.Printf("%-8v average %v, max %v, moves %v\n", v, averageTime, timedPlayers[i].maxTime, timedPlayers[i].moves)
+	}
+}
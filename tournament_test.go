@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/zerobugdebug/kaart/game"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		successes int
+		trials    int
+		wantLow   float64
+		wantHigh  float64
+	}{
+		{"half of the trials", 5, 10, 0.2366, 0.7634},
+		{"few successes", 1, 10, 0.0179, 0.4042},
+		{"large number of trials", 80, 100, 0.7112, 0.8666},
+		{"no successes", 0, 10, 0, 0.2775},
+		{"all successes", 10, 10, 0.7225, 1},
+		{"no trials", 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			low, high := wilsonInterval(tt.successes, tt.trials)
+			if math.Abs(low-tt.wantLow) > 1e-4 || math.Abs(high-tt.wantHigh) > 1e-4 {
+				t.Errorf("wilsonInterval(%v, %v) = [%.4f, %.4f], want [%.4f, %.4f]", tt.successes, tt.trials, low, high, tt.wantLow, tt.wantHigh)
+			}
+			if low < -1e-9 || high > 1+1e-9 || low > high {
+				t.Errorf("wilsonInterval(%v, %v) = [%v, %v] is not inside [0, 1]", tt.successes, tt.trials, low, high)
+			}
+		})
+	}
+}
+
+func TestTournamentStats(t *testing.T) {
+	//finishedGame will return the game without playable cards and with the remaining health of the players
+	finishedGame := func(compHealth int, userHealth int) *game.Game {
+		return game.New(game.Hand{Health: compHealth}, game.Hand{Health: userHealth}, game.Comp)
+	}
+	var stats tournamentStats
+	stats.add(finishedGame(5, 2), game.Comp)
+	stats.add(finishedGame(5, 2), game.User)
+	stats.add(finishedGame(3, 3), game.User)
+	stats.add(finishedGame(-2, 4), game.User)
+	want := tournamentStats{
+		games:  4,
+		wins:   2,
+		draws:  1,
+		losses: 1,
+		//Health of the first player is 5+2+3+4, health below zero is counted as zero
+		totalHealth: [2]int{14, 10},
+	}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestRemainingHealth(t *testing.T) {
+	tests := []struct {
+		health int
+		want   int
+	}{
+		{7, 7},
+		{0, 0},
+		{-3, 0},
+	}
+	for _, tt := range tests {
+		if got := remainingHealth(game.Hand{Health: tt.health}); got != tt.want {
+			t.Errorf("remainingHealth(%v) = %v, want %v", tt.health, got, tt.want)
+		}
+	}
+}
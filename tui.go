@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/zerobugdebug/kaart/game"
+	"golang.org/x/term"
+)
+
+//Keys recognized by the terminal UI
+const (
+	keyOther int = iota
+	keyLeft
+	keyRight
+	keyPlus
+	keyMinus
+	keyEnter
+	keyHint
+	keyQuit
+)
+
+//Escape sequences to hide and show the cursor
+const (
+	hideCursor string = "\033[?25l"
+	showCursor string = "\033[?25h"
+)
+
+//tableHeight is the number of lines in the table with two hands and two status lines
+const tableHeight int = 23
+
+//terminal is the raw mode terminal UI, nil if the user enters moves at the prompts
+var terminal *tui
+
+//tui is the full screen terminal UI in the raw mode
+type tui struct {
+	fd      int
+	state   *term.State
+	screen  *tuiScreen
+	keys    chan int
+	resized chan struct{}
+}
+
+//tuiScreen converts line feeds for the raw mode terminal and keeps the current frame to redraw it after the resize
+type tuiScreen struct {
+	out   io.Writer
+	frame bytes.Buffer
+}
+
+//Write will write to the terminal and start a new frame if the screen is cleared
+func (s *tuiScreen) Write(p []byte) (int, error) {
+	if i := bytes.LastIndex(p, []byte(clearScreen)); i >= 0 {
+		s.frame.Reset()
+		s.frame.Write(p[i:])
+	} else {
+		s.frame.Write(p)
+	}
+	if err := s.draw(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+//draw will write to the terminal without changing the current frame
+func (s *tuiScreen) draw(p []byte) error {
+	_, err := s.out.Write(bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n")))
+	return err
+}
+
+//newTUI will switch the terminal to the raw mode and start reading keys
+func newTUI() (*tui, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("standard input is not a terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	t := &tui{
+		fd:      fd,
+		state:   state,
+		screen:  &tuiScreen{out: os.Stdout},
+		keys:    make(chan int),
+		resized: make(chan struct{}, 1),
+	}
+	screen = t.screen
+	fmt.Fprint(screen, hideCursor)
+	go t.readKeys()
+	notifyResize(t.resized)
+
+	//Restore the terminal if the program is killed
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		t.close()
+		os.Exit(1)
+	}()
+	return t, nil
+}
+
+//close will restore the terminal state, it is safe to call for the nil terminal
+func (t *tui) close() {
+	if t == nil {
+		return
+	}
+	fmt.Fprint(screen, showCursor)
+	term.Restore(t.fd, t.state)
+	screen = os.Stdout
+}
+
+//readKeys will read keys from the terminal until the end of the input
+func (t *tui) readKeys() {
+	buffer := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buffer)
+		if err != nil {
+			t.keys <- keyQuit
+			return
+		}
+		t.keys <- parseKey(buffer[:n])
+	}
+}
+
+//parseKey will convert bytes of the key press to the key
+func parseKey(input []byte) int {
+	switch string(input) {
+	case "\033[D", "\033OD", "h":
+		return keyLeft
+	case "\033[C", "\033OC", "l":
+		return keyRight
+	case "+", "=", "\033[A", "\033OA":
+		return keyPlus
+	case "-", "_", "\033[B", "\033OB":
+		return keyMinus
+	case "\r", "\n":
+		return keyEnter
+	case "?":
+		return keyHint
+	case "q", "Q", "\003":
+		return keyQuit
+	}
+	return keyOther
+}
+
+//redraw will draw the current frame again after the terminal resize
+func (t *tui) redraw() {
+	t.screen.draw(t.screen.frame.Bytes())
+	t.warnSize()
+}
+
+//warnSize will show the warning below the frame if the table doesn't fit into the terminal
+func (t *tui) warnSize() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	//Some terminals don't report the size
+	if err != nil || width == 0 || height == 0 {
+		return
+	}
+	cardsNum := gameRules.HandSize
+	if cardsNum < minTableCards {
+		cardsNum = minTableCards
+	}
+	if width < frameWidth(cardsNum)+2 || height < tableHeight {
+		t.screen.draw([]byte(fmt.Sprintf("\n"+clrBadMessage+"Terminal is too small, resize it to at least %vx%v"+clrReset, frameWidth(cardsNum)+2, tableHeight)))
+	}
+}
+
+//quit will restore the terminal and exit, the game is abandoned
+func (t *tui) quit() {
+	t.close()
+	fmt.Println("\nGame abandoned")
+	os.Exit(0)
+}
+
+//waitEnter will wait for the Enter key, screen is redrawn on the terminal resize
+func (t *tui) waitEnter() {
+	for {
+		select {
+		case key := <-t.keys:
+			switch key {
+			case keyEnter:
+				fmt.Fprintln(screen)
+				return
+			case keyQuit:
+				t.quit()
+			}
+		case <-t.resized:
+			t.redraw()
+		}
+	}
+}
+
+//chooseMove will let the user select the card with the arrow keys and the power with +/-
+func (t *tui) chooseMove(state State) (int, int) {
+	hand := state.Hand.Copy()
+	//Start from the first playable card without power
+	hand.SelectedCard = 0
+	for !hand.Cards[hand.SelectedCard].Playable {
+		hand.SelectedCard++
+	}
+	hand.SelectedPower = 0
+	hint := ""
+	for {
+		var hands [2]game.Hand
+		hands[state.Seat] = hand
+		hands[game.Opponent(state.Seat)] = state.Opponent
+		hands[game.Comp], hands[game.User] = visibleHands(hands[game.Comp], hands[game.User])
+		//User always sees the own selection
+		hands[state.Seat].SelectedPower = hand.SelectedPower
+		drawTable(hands[game.Comp], hands[game.User])
+		fmt.Fprintf(screen, "YOUR TURN: card %v, power %v of %v\n", hand.SelectedCard+1, hand.SelectedPower, hand.Power)
+		fmt.Fprint(screen, "←/→ card, +/- power, Enter to play, ? for hint, q to quit")
+		if hint != "" {
+			fmt.Fprint(screen, "\n"+strings.TrimSuffix(hint, "\n"))
+		}
+		t.warnSize()
+
+		select {
+		case key := <-t.keys:
+			switch key {
+			case keyLeft:
+				hand.SelectedCard = nextPlayableCard(hand, -1)
+			case keyRight:
+				hand.SelectedCard = nextPlayableCard(hand, 1)
+			case keyPlus:
+				if hand.SelectedPower < hand.Power {
+					hand.SelectedPower++
+				}
+			case keyMinus:
+				if hand.SelectedPower > 0 {
+					hand.SelectedPower--
+				}
+			case keyEnter:
+				fmt.Fprintln(screen)
+				return hand.SelectedCard, hand.SelectedPower
+			case keyHint:
+				fmt.Fprint(screen, "\nCalculating the hint...")
+				hint = moveHint(state)
+			case keyQuit:
+				t.quit()
+			}
+		case <-t.resized:
+		}
+	}
+}
+
+//nextPlayableCard will return the next playable card after the selected card in the direction, selection wraps around the hand
+func nextPlayableCard(hand game.Hand, direction int) int {
+	card := hand.SelectedCard
+	for range hand.Cards {
+		card = (card + direction + len(hand.Cards)) % len(hand.Cards)
+		if hand.Cards[card].Playable {
+			return card
+		}
+	}
+	return hand.SelectedCard
+}
+
+//pause will show the message and wait for the user to press Enter
+func pause(message string) {
+	fmt.Fprint(screen, message)
+	if terminal != nil {
+		terminal.waitEnter()
+		return
+	}
+	bufio.NewReader(os.Stdin).ReadBytes('\n')
+}
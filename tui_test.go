@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{"left arrow", "\033[D", keyLeft},
+		{"left arrow in the application mode", "\033OD", keyLeft},
+		{"vi left", "h", keyLeft},
+		{"right arrow", "\033[C", keyRight},
+		{"right arrow in the application mode", "\033OC", keyRight},
+		{"vi right", "l", keyRight},
+		{"plus", "+", keyPlus},
+		{"plus without shift", "=", keyPlus},
+		{"up arrow", "\033[A", keyPlus},
+		{"up arrow in the application mode", "\033OA", keyPlus},
+		{"minus", "-", keyMinus},
+		{"minus with shift", "_", keyMinus},
+		{"down arrow", "\033[B", keyMinus},
+		{"down arrow in the application mode", "\033OB", keyMinus},
+		{"carriage return", "\r", keyEnter},
+		{"line feed", "\n", keyEnter},
+		{"hint", "?", keyHint},
+		{"quit", "q", keyQuit},
+		{"quit with shift", "Q", keyQuit},
+		{"ctrl+c", "\003", keyQuit},
+		{"other letter", "x", keyOther},
+		{"escape", "\033", keyOther},
+		{"unknown escape sequence", "\033[H", keyOther},
+		{"several keys", "hl", keyOther},
+		{"empty input", "", keyOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKey([]byte(tt.input)); got != tt.want {
+				t.Errorf("parseKey(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextPlayableCard(t *testing.T) {
+	tests := []struct {
+		name      string
+		cardsNum  int
+		played    []int
+		selected  int
+		direction int
+		want      int
+	}{
+		{"right to the next card", 4, nil, 1, 1, 2},
+		{"left to the previous card", 4, nil, 1, -1, 0},
+		{"right wraps to the first card", 4, nil, 3, 1, 0},
+		{"left wraps to the last card", 4, nil, 0, -1, 3},
+		{"right skips the played cards", 5, []int{2, 3}, 1, 1, 4},
+		{"left skips the played cards", 5, []int{2, 3}, 4, -1, 1},
+		{"right wraps over the played cards", 5, []int{0, 4}, 3, 1, 1},
+		{"left wraps over the played cards", 5, []int{3, 4}, 0, -1, 2},
+		{"only playable card stays selected", 4, []int{0, 1, 3}, 2, 1, 2},
+		{"only playable card stays selected on the left", 4, []int{0, 1, 3}, 2, -1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hand := testGAHand(tt.cardsNum, 0, tt.played...)
+			hand.SelectedCard = tt.selected
+			if got := nextPlayableCard(hand, tt.direction); got != tt.want {
+				t.Errorf("nextPlayableCard(%v) from card %v = %v, want %v", tt.direction, tt.selected, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+//resizePollInterval is how often the terminal size is checked, Windows console has no resize signal
+const resizePollInterval time.Duration = 250 * time.Millisecond
+
+//notifyResize will send to the channel when the terminal is resized
+func notifyResize(resized chan<- struct{}) {
+	go func() {
+		width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+		for range time.Tick(resizePollInterval) {
+			newWidth, newHeight, err := term.GetSize(int(os.Stdout.Fd()))
+			if err != nil || (newWidth == width && newHeight == height) {
+				continue
+			}
+			width, height = newWidth, newHeight
+			select {
+			case resized <- struct{}{}:
+			default:
+			}
+		}
+	}()
+}